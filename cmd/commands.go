@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/luthfiarsyad/mms/config"
+)
+
+const usage = `usage:
+  mms                             run the server
+  mms config print [config.yaml]  print the effective configuration, secrets masked`
+
+// runCommand runs a subcommand and returns the process exit code.
+func runCommand(args []string) int {
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" && len(args) <= 3 {
+		path := ""
+		if len(args) == 3 {
+			path = args[2]
+		}
+		return printConfig(path)
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+// printConfig prints the configuration the server would run with, then
+// reports validation problems, if any, on stderr.
+func printConfig(path string) int {
+	cfg, err := config.Read(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+	out, err := cfg.Print()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print config: %v\n", err)
+		return 1
+	}
+	os.Stdout.Write(out)
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid config:\n%v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -1,17 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gin-gonic/gin"
 	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/app"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
 	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
-	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/mysql"
-	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
-	"github.com/luthfiarsyad/mms/internal/interface/http"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// --- Load config ---
 	if err := config.Load(""); err != nil {
 		log.Fatalf("failed to load config: %v", err)
@@ -21,36 +27,21 @@ func main() {
 		log.Fatalf("config is nil after load")
 	}
 
-	// --- Initialize Zerolog ---
-	logger.Init(cfg.Log.Level)
-	log := logger.Get()
-	log.Info().Msg("Logger initialized")
-
-	// --- Initialize Database ---
-	if _, err := mysql.Connect(); err != nil {
-		log.Fatal().Err(err).Msg("Failed to connect to database")
-	}
-	log.Info().Msg("Database connected")
-
-	// --- Setup Gin ---
-	gin.SetMode(cfg.Server.Mode)
-	r := gin.New()
-
-	// Apply middlewares
-	r.Use(gin.Recovery())
-	r.Use(middleware.RequestLogger())
-
-	// --- Setup routes ---
-	http.SetupRoutes(r)
+	// --- Lifecycle: workers stop and resources close on shutdown ---
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	lc := lifecycle.New(context.Background())
 
-	// --- Run server ---
-	addr := cfg.Server.Address
-	if addr == "" {
-		addr = ":8080"
+	// --- Build the application: logger, tracing, database, handlers ---
+	a, err := app.New(ctx, cfg, lc)
+	if err != nil {
+		_ = lc.Stop(context.Background())
+		log.Fatalf("failed to start: %v", err)
 	}
-	log.Info().Msgf("Server starting on %s", addr)
 
-	if err := r.Run(addr); err != nil {
-		log.Fatal().Err(err).Msg("Server failed")
+	// --- Run server until SIGINT/SIGTERM, then drain and shut down ---
+	if err := a.Run(ctx); err != nil {
+		logger.L.Fatal().Err(err).Msg("Server failed")
 	}
+	logger.L.Info().Msg("Server stopped")
 }
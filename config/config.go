@@ -1,49 +1,283 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Paseto   PasetoConfig   `mapstructure:"paseto"`
-	Log      LogConfig      `mapstructure:"log"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	Paseto      PasetoConfig      `mapstructure:"paseto"`
+	Password    PasswordConfig    `mapstructure:"password"`
+	Session     SessionConfig     `mapstructure:"session"`
+	Trash       TrashConfig       `mapstructure:"trash"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	Health      HealthConfig      `mapstructure:"health"`
+	Metrics     MetricsConfig     `mapstructure:"metrics"`
+	Tracing     TracingConfig     `mapstructure:"tracing"`
+	Log         LogConfig         `mapstructure:"log"`
+	Admin       AdminConfig       `mapstructure:"admin"`
+	// Sections and fields tagged reload:"true" are applied on a config file
+	// change; see Reload.
+	CORS      CORSConfig      `mapstructure:"cors" reload:"true"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit" reload:"true"`
+
+	file string // config file read, if any
+}
+
+// CORSConfig lists the browser origins allowed to call the API; "*" allows
+// any origin, but never with credentials. With no origins, no CORS headers
+// are sent.
+type CORSConfig struct {
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowedMethods   []string `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string `mapstructure:"allowed_headers"`
+	AllowCredentials bool     `mapstructure:"allow_credentials"`
+	MaxAgeSeconds    int      `mapstructure:"max_age_seconds"`
+}
+
+// RateLimitConfig limits each client IP address to RequestsPerSecond with
+// bursts of up to Burst requests.
+type RateLimitConfig struct {
+	Enabled           bool    `mapstructure:"enabled"`
+	RequestsPerSecond float64 `mapstructure:"requests_per_second"`
+	Burst             int     `mapstructure:"burst"`
 }
 
 type ServerConfig struct {
 	Mode    string `mapstructure:"mode"`
 	Address string `mapstructure:"address"`
+	// ProblemDetails renders every error as RFC 7807 application/problem+json.
+	// Clients can also ask for it per request through the Accept header.
+	ProblemDetails bool `mapstructure:"problem_details"`
+	// TrustedProxies are the addresses or CIDR ranges of the reverse proxies
+	// whose X-Forwarded-For and X-Real-IP headers are believed. The client
+	// address used for rate limits, sessions and the audit log is taken from
+	// those headers only for requests that come through one of them; by
+	// default none are trusted and the address is that of the connection.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	// Timeouts of the http.Server. Zero values use the server package defaults.
+	ReadTimeoutSeconds  int `mapstructure:"read_timeout_seconds"`
+	WriteTimeoutSeconds int `mapstructure:"write_timeout_seconds"`
+	IdleTimeoutSeconds  int `mapstructure:"idle_timeout_seconds"`
+	// ShutdownDelaySeconds keeps serving after readiness starts failing, so a
+	// load balancer can stop routing traffic here before the listener closes.
+	ShutdownDelaySeconds int `mapstructure:"shutdown_delay_seconds"`
+	// ShutdownTimeoutSeconds bounds draining requests, and separately
+	// stopping workers and closing resources, so slow requests cannot leave
+	// the database unclosed.
+	ShutdownTimeoutSeconds int `mapstructure:"shutdown_timeout_seconds"`
 }
 
+// DatabaseConfig selects the storage backend and describes its connection.
+// Zero values use the defaults of the backend's package.
 type DatabaseConfig struct {
+	// Driver is "mysql", "postgres", "sqlite" or "memory". SQLite keeps
+	// everything in the file at Path and ignores the connection settings
+	// below. Postgres ignores the charset and the read and write timeouts.
+	// Memory needs no settings and forgets everything on restart.
+	Driver string `mapstructure:"driver"`
+	Path   string `mapstructure:"path"`
+
 	Host     string `mapstructure:"host"`
 	Port     int    `mapstructure:"port"`
 	User     string `mapstructure:"user"`
-	Password string `mapstructure:"password"`
+	Password string `mapstructure:"password" secret:"true"`
 	Name     string `mapstructure:"name"`
-	DSN      string `mapstructure:"dsn"`
+	// DSN, when set, is used verbatim instead of the connection fields
+	// above and the driver options below (timeouts, TLS, charset).
+	DSN string `mapstructure:"dsn" secret:"true"`
+
+	// Connection pool.
+	MaxOpenConns           int `mapstructure:"max_open_conns"`
+	MaxIdleConns           int `mapstructure:"max_idle_conns"`
+	ConnMaxLifetimeSeconds int `mapstructure:"conn_max_lifetime_seconds"`
+	ConnMaxIdleTimeSeconds int `mapstructure:"conn_max_idle_time_seconds"`
+
+	// Driver timeouts for dialing and for each network read and write.
+	ConnectTimeoutSeconds int `mapstructure:"connect_timeout_seconds"`
+	ReadTimeoutSeconds    int `mapstructure:"read_timeout_seconds"`
+	WriteTimeoutSeconds   int `mapstructure:"write_timeout_seconds"`
+
+	// TLSMode follows the MySQL client's --ssl-mode: "disabled", "preferred",
+	// "required" (encrypted, server not verified), "verify_ca" or
+	// "verify_identity" (also checks the host name). TLSCAFile is a PEM
+	// bundle trusted by the verify modes instead of the system roots.
+	TLSMode   string `mapstructure:"tls_mode"`
+	TLSCAFile string `mapstructure:"tls_ca_file"`
+
+	Charset   string `mapstructure:"charset"`
+	Collation string `mapstructure:"collation"` // empty uses the charset's default
+
+	// The initial connect is retried up to ConnectRetries times, doubling the
+	// wait between attempts up to ConnectMaxBackoffSeconds, so the server can
+	// start before the database is up.
+	ConnectRetries           int `mapstructure:"connect_retries"`
+	ConnectMaxBackoffSeconds int `mapstructure:"connect_max_backoff_seconds"`
+
+	// Replicas are the host or host:port addresses of MySQL read replicas,
+	// which are reached with the primary's credentials, TLS settings and
+	// timeouts; a missing port is the primary's. Transaction lists and
+	// reports read from a healthy replica unless the user wrote within
+	// ReplicaStickySeconds; writes always go to the primary.
+	Replicas                    []string `mapstructure:"replicas" secret:"true"`
+	ReplicaStickySeconds        int      `mapstructure:"replica_sticky_seconds"`
+	ReplicaCheckIntervalSeconds int      `mapstructure:"replica_check_interval_seconds"`
 }
 
 type PasetoConfig struct {
-	SymmetricKey  string `mapstructure:"symmetric_key"`
-	ExpireMinutes int    `mapstructure:"expire_minutes"`
+	SymmetricKey  string `mapstructure:"symmetric_key" secret:"true"`
+	ExpireMinutes int    `mapstructure:"expire_minutes" reload:"true"` // access token and login session lifetime
+}
+
+// PasswordConfig selects the password hashing algorithm for new hashes.
+// Zero values fall back to the defaults of the security package.
+type PasswordConfig struct {
+	Algorithm  string               `mapstructure:"algorithm"` // "argon2id" or "bcrypt"
+	BcryptCost int                  `mapstructure:"bcrypt_cost"`
+	Argon2     Argon2Config         `mapstructure:"argon2"`
+	Policy     PasswordPolicyConfig `mapstructure:"policy"`
+}
+
+type Argon2Config struct {
+	MemoryKiB   uint32 `mapstructure:"memory_kib"`
+	Iterations  uint32 `mapstructure:"iterations"`
+	Parallelism uint8  `mapstructure:"parallelism"`
+	SaltLength  uint32 `mapstructure:"salt_length"`
+	KeyLength   uint32 `mapstructure:"key_length"`
+}
+
+// PasswordPolicyConfig controls which new passwords are accepted.
+// BreachedListFile optionally points to a local, sorted file of SHA-1 digests.
+// A zero MaxLength is 128 characters, or 72 with bcrypt.
+type PasswordPolicyConfig struct {
+	MinLength            int    `mapstructure:"min_length"`
+	MaxLength            int    `mapstructure:"max_length"`
+	RequireUpper         bool   `mapstructure:"require_upper"`
+	RequireLower         bool   `mapstructure:"require_lower"`
+	RequireDigit         bool   `mapstructure:"require_digit"`
+	RequireSymbol        bool   `mapstructure:"require_symbol"`
+	DisallowPersonalInfo bool   `mapstructure:"disallow_personal_info"`
+	BreachedListFile     string `mapstructure:"breached_list_file"`
+}
+
+// SessionConfig tunes how AuthMiddleware checks login sessions.
+// Revocations made on another instance are noticed after at most CacheTTLSeconds.
+type SessionConfig struct {
+	CacheTTLSeconds         int `mapstructure:"cache_ttl_seconds"`
+	LastSeenIntervalSeconds int `mapstructure:"last_seen_interval_seconds"`
+}
+
+// TrashConfig controls how long deleted transactions can be restored. With
+// RetentionDays 0 they are kept until restored.
+type TrashConfig struct {
+	RetentionDays        int `mapstructure:"retention_days"`
+	PurgeIntervalMinutes int `mapstructure:"purge_interval_minutes"`
+}
+
+// IdempotencyConfig controls how long the response to a request sent with
+// an Idempotency-Key header is kept for replays, and how large its body may
+// be, as it is read into memory. A key still in progress after
+// LockTimeoutSeconds is taken to be abandoned and may be claimed again.
+// Zero values use the defaults: 24 hours, purged hourly, 1 MiB bodies, a
+// one minute lock.
+type IdempotencyConfig struct {
+	TTLHours             int `mapstructure:"ttl_hours"`
+	PurgeIntervalMinutes int `mapstructure:"purge_interval_minutes"`
+	MaxBodyKB            int `mapstructure:"max_body_kb"`
+	LockTimeoutSeconds   int `mapstructure:"lock_timeout_seconds"`
+}
+
+// HealthConfig tunes the readiness checks. Zero values use the defaults of
+// the health package.
+type HealthConfig struct {
+	CacheTTLSeconds int     `mapstructure:"cache_ttl_seconds"`
+	TimeoutSeconds  int     `mapstructure:"timeout_seconds"`
+	PoolSaturation  float64 `mapstructure:"pool_saturation"` // share of max open connections in use, e.g. 0.9
+}
+
+// MetricsConfig controls the Prometheus endpoint. When Username is set the
+// endpoint requires HTTP basic auth.
+type MetricsConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`
+	Path     string `mapstructure:"path"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" secret:"true"`
+}
+
+// TracingConfig selects where OpenTelemetry spans go: "otlp", "stdout" or
+// "off". Endpoint is the OTLP/HTTP collector, e.g. "localhost:4318".
+type TracingConfig struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"` // 0 < ratio <= 1, defaults to 1
 }
 
 type LogConfig struct {
-	Level string `mapstructure:"level"`
+	Level  string `mapstructure:"level" reload:"true"`
+	Format string `mapstructure:"format"` // "console" (default) or "json"
+	Output string `mapstructure:"output"` // "stdout" (default) or "file"
+	// Redact masks emails, tokens, API keys and password fields in every
+	// log line.
+	Redact   bool              `mapstructure:"redact"`
+	File     LogFileConfig     `mapstructure:"file"`
+	Sampling LogSamplingConfig `mapstructure:"sampling"`
 }
 
-// Cfg holds the loaded configuration for the whole application.
-// After calling Load, other packages can read config via config.Get()
-var Cfg *Config
+// LogFileConfig controls the log file used when output is "file". The file
+// is rotated when it reaches MaxSizeMB or is older than
+// RotateIntervalHours, whichever comes first; zero disables either rule.
+type LogFileConfig struct {
+	Path                string `mapstructure:"path"`
+	MaxSizeMB           int    `mapstructure:"max_size_mb"`
+	RotateIntervalHours int    `mapstructure:"rotate_interval_hours"`
+	MaxBackups          int    `mapstructure:"max_backups"` // 0 keeps every rotated file
+}
 
-// Load reads configuration from config/config.yaml (or a custom path if provided).
-// It does NOT set any defaults — missing required fields will return an error.
+// LogSamplingConfig thins out debug logs: each second the first DebugBurst
+// debug events are written, then only one in DebugEvery. Zero disables
+// sampling.
+type LogSamplingConfig struct {
+	DebugBurst uint32 `mapstructure:"debug_burst"`
+	DebugEvery uint32 `mapstructure:"debug_every"`
+}
+
+// AdminConfig protects the /admin endpoints with basic auth. The endpoints
+// are not registered while Username is empty.
+type AdminConfig struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" secret:"true"`
+}
+
+// Load reads the configuration (see Read), validates it and makes it
+// available through Get.
+// All validation problems are reported together.
 func Load(configPath string) error {
+	cfg, err := Read(configPath)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	loadedFrom = cfg.file
+	Set(cfg)
+	return nil
+}
+
+// Read builds the configuration from, in increasing priority: the defaults,
+// config/config.yaml (or configPath), MMS_* environment variables and
+// MMS_*_FILE secret files. The config file is optional unless configPath is
+// given. Read does not validate the result.
+func Read(configPath string) (*Config, error) {
 	v := viper.New()
+	setDefaults(v)
 
 	if configPath == "" {
 		// look for config/config.yaml relative to project root or current working dir
@@ -56,31 +290,20 @@ func Load(configPath string) error {
 	}
 
 	if err := v.ReadInConfig(); err != nil {
-		return fmt.Errorf("read config: %w", err)
+		var notFound viper.ConfigFileNotFoundError
+		if configPath != "" || !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("read config: %w", err)
+		}
 	}
 
-	var cfg Config
-	if err := v.Unmarshal(&cfg); err != nil {
-		return fmt.Errorf("unmarshal config: %w", err)
+	if err := bindEnv(v); err != nil {
+		return nil, err
 	}
 
-	// Basic required-field validation (fail early if values are missing)
-	if cfg.Server.Address == "" || cfg.Server.Mode == "" {
-		return fmt.Errorf("config: server.address and server.mode must be set")
-	}
-	// Database: either full DSN or host/user/name must be provided
-	if cfg.Database.DSN == "" {
-		if cfg.Database.Host == "" || cfg.Database.User == "" || cfg.Database.Name == "" {
-			return fmt.Errorf("config: either database.dsn or (database.host, database.user, database.name) must be set")
-		}
-	}
-	if cfg.Paseto.SymmetricKey == "" {
-		return fmt.Errorf("config: paseto.symmetric_key must be set")
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
-
-	Cfg = &cfg
-	return nil
+	cfg.file = v.ConfigFileUsed()
+	return &cfg, nil
 }
-
-// Get returns the loaded Config pointer (may be nil if Load wasn't called or failed)
-func Get() *Config { return Cfg }
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix prefixes the environment variable of every key: server.address
+// is MMS_SERVER_ADDRESS, and MMS_SERVER_ADDRESS_FILE names a file holding
+// the value.
+const EnvPrefix = "MMS"
+
+// fileSuffix marks a variable naming a file, typically a mounted secret.
+const fileSuffix = "_FILE"
+
+// EnvName returns the environment variable overriding key.
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Keys lists every configuration key, e.g. "database.password", in
+// declaration order.
+func Keys() []string {
+	return keys(reflect.TypeOf(Config{}), "")
+}
+
+func keys(t reflect.Type, prefix string) []string {
+	var out []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		if f.Type.Kind() == reflect.Struct {
+			out = append(out, keys(f.Type, key+".")...)
+			continue
+		}
+		out = append(out, key)
+	}
+	return out
+}
+
+// bindEnv makes every key overridable through its environment variable and
+// loads *_FILE variables. Viper only consults the environment for keys it
+// knows about, so each key is bound explicitly. Unreadable files are all
+// reported together.
+func bindEnv(v *viper.Viper) error {
+	var errs []error
+	for _, key := range Keys() {
+		env := EnvName(key)
+		if err := v.BindEnv(key, env); err != nil {
+			return err
+		}
+
+		path, ok := os.LookupEnv(env + fileSuffix)
+		if !ok {
+			continue
+		}
+		if _, both := os.LookupEnv(env); both {
+			errs = append(errs, fmt.Errorf("%s and %s are both set", env, env+fileSuffix))
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", env+fileSuffix, err))
+			continue
+		}
+		// Secret files usually end with a newline that is not part of the value.
+		v.Set(key, strings.TrimRight(string(data), "\r\n"))
+	}
+	return errors.Join(errs...)
+}
+
+// setDefaults registers the values used when neither the config file nor
+// the environment sets a key. Secrets have no default.
+func setDefaults(v *viper.Viper) {
+	defaults := map[string]any{
+		"server.mode":                     "release",
+		"server.address":                  ":8080",
+		"server.read_timeout_seconds":     15,
+		"server.write_timeout_seconds":    30,
+		"server.idle_timeout_seconds":     60,
+		"server.shutdown_timeout_seconds": 20,
+
+		"database.driver": "mysql",
+		"database.path":   "mms.db",
+		"database.host":   "127.0.0.1",
+		"database.port":   3306,
+		"database.user":   "root",
+		"database.name":   "mms_db",
+
+		"database.max_open_conns":                 25,
+		"database.max_idle_conns":                 10,
+		"database.conn_max_lifetime_seconds":      300,
+		"database.conn_max_idle_time_seconds":     60,
+		"database.connect_timeout_seconds":        10,
+		"database.read_timeout_seconds":           30,
+		"database.write_timeout_seconds":          30,
+		"database.tls_mode":                       "disabled",
+		"database.charset":                        "utf8mb4",
+		"database.connect_retries":                10,
+		"database.connect_max_backoff_seconds":    10,
+		"database.replica_sticky_seconds":         5,
+		"database.replica_check_interval_seconds": 5,
+
+		"paseto.expire_minutes": 1440,
+
+		"password.algorithm":                     "argon2id",
+		"password.bcrypt_cost":                   10,
+		"password.policy.min_length":             8,
+		"password.policy.max_length":             0,
+		"password.policy.require_lower":          true,
+		"password.policy.require_digit":          true,
+		"password.policy.disallow_personal_info": true,
+
+		"session.cache_ttl_seconds":          30,
+		"session.last_seen_interval_seconds": 60,
+
+		"trash.retention_days":         30,
+		"trash.purge_interval_minutes": 60,
+
+		"idempotency.ttl_hours":              24,
+		"idempotency.purge_interval_minutes": 60,
+		"idempotency.max_body_kb":            1024,
+		"idempotency.lock_timeout_seconds":   60,
+
+		"health.cache_ttl_seconds": 2,
+		"health.timeout_seconds":   2,
+		"health.pool_saturation":   0.9,
+
+		"metrics.enabled": true,
+		"metrics.path":    "/metrics",
+
+		"tracing.exporter":     "off",
+		"tracing.endpoint":     "localhost:4318",
+		"tracing.insecure":     true,
+		"tracing.service_name": "mms",
+		"tracing.sample_ratio": 1.0,
+
+		"log.level":                      "info",
+		"log.format":                     "json",
+		"log.output":                     "stdout",
+		"log.redact":                     true,
+		"log.file.path":                  "logs/mms.log",
+		"log.file.max_size_mb":           100,
+		"log.file.rotate_interval_hours": 24,
+		"log.file.max_backups":           7,
+
+		"cors.allowed_methods": []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		"cors.allowed_headers": []string{"Authorization", "Content-Type", "X-Request-ID", "If-Match", "Idempotency-Key"},
+		"cors.max_age_seconds": 600,
+
+		"rate_limit.requests_per_second": 10,
+		"rate_limit.burst":               20,
+	}
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Masked replaces the value of secrets, fields tagged secret:"true".
+const Masked = "******"
+
+// Print renders c as YAML keyed like config.yaml, with secrets masked.
+func (c *Config) Print() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(toMap(reflect.ValueOf(*c))); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toMap(v reflect.Value) map[string]any {
+	out := make(map[string]any)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		switch {
+		case f.Type.Kind() == reflect.Struct:
+			out[name] = toMap(v.Field(i))
+		case f.Tag.Get("secret") == "true" && !v.Field(i).IsZero():
+			out[name] = Masked
+		default:
+			out[name] = v.Field(i).Interface()
+		}
+	}
+	return out
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// current holds the active configuration. It is replaced as a whole and
+// never modified in place, so a *Config returned by Get is a consistent
+// snapshot that callers must treat as read-only.
+var current atomic.Pointer[Config]
+
+// loadedFrom is the config file read by Load, if any.
+var loadedFrom string
+
+var (
+	// setMu serializes Set, so subscribers see changes one at a time and in
+	// order; reloadMu serializes Reload.
+	setMu    sync.Mutex
+	reloadMu sync.Mutex
+
+	subsMu  sync.Mutex
+	subs    []subscriber
+	nextSub uint64
+)
+
+type subscriber struct {
+	id uint64
+	fn func(old, new *Config)
+}
+
+// Get returns the active configuration (nil if Load wasn't called or failed).
+func Get() *Config { return current.Load() }
+
+// Set makes cfg the active configuration and runs the subscribers. Setting
+// the first configuration, or clearing it with nil, notifies no one.
+func Set(cfg *Config) {
+	setMu.Lock()
+	defer setMu.Unlock()
+
+	old := current.Swap(cfg)
+	if old == nil || cfg == nil || old == cfg {
+		return
+	}
+	subsMu.Lock()
+	fns := make([]func(old, new *Config), len(subs))
+	for i, s := range subs {
+		fns[i] = s.fn
+	}
+	subsMu.Unlock()
+	for _, fn := range fns {
+		fn(old, cfg)
+	}
+}
+
+// Subscribe registers fn to run, in registration order, after each change of
+// the active configuration. The returned function removes it.
+func Subscribe(fn func(old, new *Config)) (cancel func()) {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+	nextSub++
+	id := nextSub
+	subs = append(subs, subscriber{id: id, fn: fn})
+	return func() {
+		subsMu.Lock()
+		defer subsMu.Unlock()
+		for i, s := range subs {
+			if s.id == id {
+				subs = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// OnChange calls apply with the value selected by sel, typically one
+// section, whenever a configuration change modifies it:
+//
+//	config.OnChange(func(c *config.Config) config.CORSConfig { return c.CORS }, cors.Update)
+func OnChange[T any](sel func(*Config) T, apply func(T)) (cancel func()) {
+	return Subscribe(func(old, new *Config) {
+		if v := sel(new); !reflect.DeepEqual(sel(old), v) {
+			apply(v)
+		}
+	})
+}
+
+// Reload reads the configuration again from the sources Load used and
+// applies it. Only fields tagged reload:"true" (or inside such a section)
+// may change at runtime; changes to any other key are rejected: the running
+// value is kept and the key is returned in rejected. An invalid
+// configuration is not applied at all.
+func Reload() (rejected []string, err error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	next, err := Read(loadedFrom)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	if old := Get(); old != nil {
+		rejected = keepStatic(reflect.ValueOf(old).Elem(), reflect.ValueOf(next).Elem(), "", false)
+	}
+	Set(next)
+	return rejected, nil
+}
+
+// keepStatic copies every non-reloadable field that differs from old back
+// into next and returns the keys of those fields.
+func keepStatic(old, next reflect.Value, prefix string, reloadable bool) []string {
+	var rejected []string
+	t := next.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Tag.Get("mapstructure")
+		if name == "" || name == "-" {
+			continue
+		}
+		key := prefix + name
+		ok := reloadable || f.Tag.Get("reload") == "true"
+		if f.Type.Kind() == reflect.Struct {
+			rejected = append(rejected, keepStatic(old.Field(i), next.Field(i), key+".", ok)...)
+			continue
+		}
+		if !ok && !reflect.DeepEqual(old.Field(i).Interface(), next.Field(i).Interface()) {
+			next.Field(i).Set(old.Field(i))
+			rejected = append(rejected, key)
+		}
+	}
+	return rejected
+}
+
+// Watch calls Reload whenever the config file read by Load changes and
+// passes its outcome to report. It fails when Load did not read a file.
+func Watch(report func(rejected []string, err error)) error {
+	if loadedFrom == "" {
+		return errors.New("no config file to watch")
+	}
+	v := viper.New()
+	v.SetConfigFile(loadedFrom)
+	v.OnConfigChange(func(fsnotify.Event) {
+		report(Reload())
+	})
+	v.WatchConfig()
+	return nil
+}
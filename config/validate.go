@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+)
+
+// Validate checks the whole configuration and returns every problem found,
+// joined with errors.Join, or nil.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		check(slices.Contains(allowed, value), "%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value)
+	}
+
+	oneOf("server.mode", c.Server.Mode, "debug", "release", "test")
+	check(c.Server.Address != "", "server.address must be set")
+	check(c.Server.ShutdownTimeoutSeconds >= 0, "server.shutdown_timeout_seconds must not be negative")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies must hold IP addresses or CIDR ranges, got %q", proxy)
+	}
+
+	oneOf("database.driver", c.Database.Driver, "", "mysql", "postgres", "sqlite", "memory")
+	// Database: either full DSN or host/user/name must be provided
+	switch {
+	case c.Database.Driver == "memory": // nothing to connect to
+	case c.Database.Driver == "sqlite":
+		check(c.Database.Path != "", "database.path must be set for the sqlite driver")
+	case c.Database.DSN == "":
+		check(c.Database.Host != "" && c.Database.User != "" && c.Database.Name != "",
+			"either database.dsn or (database.host, database.user, database.name) must be set")
+		check(c.Database.Port > 0 && c.Database.Port < 65536, "database.port must be between 1 and 65535, got %d", c.Database.Port)
+		oneOf("database.tls_mode", c.Database.TLSMode, "", "disabled", "preferred", "required", "verify_ca", "verify_identity")
+		check(c.Database.TLSCAFile == "" || strings.HasPrefix(c.Database.TLSMode, "verify_"),
+			"database.tls_ca_file requires database.tls_mode verify_ca or verify_identity")
+	}
+	check(c.Database.MaxOpenConns >= 0 && c.Database.MaxIdleConns >= 0, "database pool sizes must not be negative")
+	check(c.Database.ConnectRetries >= 0, "database.connect_retries must not be negative")
+	check(c.Database.ReplicaStickySeconds >= 0 && c.Database.ReplicaCheckIntervalSeconds >= 0,
+		"database replica intervals must not be negative")
+
+	if c.Paseto.SymmetricKey == "" {
+		check(false, "paseto.symmetric_key must be set")
+	} else if key, err := base64.StdEncoding.DecodeString(c.Paseto.SymmetricKey); err != nil {
+		check(false, "paseto.symmetric_key must be base64: %v", err)
+	} else {
+		check(len(key) == 32, "paseto.symmetric_key must decode to 32 bytes, got %d", len(key))
+	}
+	check(c.Paseto.ExpireMinutes > 0, "paseto.expire_minutes must be positive")
+
+	oneOf("password.algorithm", strings.ToLower(c.Password.Algorithm), "argon2id", "bcrypt")
+	// Stored argon2id hashes outside these limits fail to verify.
+	a := c.Password.Argon2
+	check(a.Iterations <= 100, "password.argon2.iterations must be at most 100")
+	check(a.MemoryKiB <= 4<<20, "password.argon2.memory_kib must be at most 4194304")
+	check(a.KeyLength == 0 || a.KeyLength >= 16, "password.argon2.key_length must be at least 16")
+	p := c.Password.Policy
+	maxLength := p.MaxLength
+	if strings.EqualFold(c.Password.Algorithm, "bcrypt") {
+		// bcrypt takes at most 72 bytes; a zero max_length means exactly that.
+		check(maxLength <= 72, "password.policy.max_length must be at most 72 with bcrypt, got %d", maxLength)
+		if maxLength == 0 {
+			maxLength = 72
+		}
+	}
+	check(maxLength == 0 || p.MinLength <= maxLength, "password.policy.min_length must not exceed password.policy.max_length")
+
+	check(c.Trash.RetentionDays >= 0, "trash.retention_days must not be negative")
+	check(c.Trash.RetentionDays == 0 || c.Trash.PurgeIntervalMinutes > 0, "trash.purge_interval_minutes must be positive")
+	check(c.Idempotency.TTLHours >= 0, "idempotency.ttl_hours must not be negative")
+	check(c.Idempotency.PurgeIntervalMinutes >= 0, "idempotency.purge_interval_minutes must not be negative")
+	check(c.Idempotency.MaxBodyKB >= 0, "idempotency.max_body_kb must not be negative")
+	check(c.Idempotency.LockTimeoutSeconds >= 0, "idempotency.lock_timeout_seconds must not be negative")
+
+	check(c.Health.PoolSaturation >= 0 && c.Health.PoolSaturation <= 1, "health.pool_saturation must be between 0 and 1")
+	check(c.Metrics.Username == "" || c.Metrics.Password != "", "metrics.password must be set when metrics.username is")
+	check(c.Admin.Username == "" || c.Admin.Password != "", "admin.password must be set when admin.username is")
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "", "off", "stdout", "otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	oneOf("log.level", c.Log.Level, "", "trace", "debug", "info", "warn", "error", "fatal", "panic", "disabled")
+	oneOf("log.format", c.Log.Format, "", "console", "json")
+	oneOf("log.output", c.Log.Output, "", "stdout", "file")
+	check(c.Log.Output != "file" || c.Log.File.Path != "", "log.file.path must be set when log.output is file")
+
+	check(c.CORS.MaxAgeSeconds >= 0, "cors.max_age_seconds must not be negative")
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"),
+		"cors.allow_credentials cannot be combined with the \"*\" origin; list the origins instead")
+	if c.RateLimit.Enabled {
+		check(c.RateLimit.RequestsPerSecond > 0, "rate_limit.requests_per_second must be positive")
+		check(c.RateLimit.Burst > 0, "rate_limit.burst must be positive")
+	}
+
+	return errors.Join(errs...)
+}
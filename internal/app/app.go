@@ -0,0 +1,101 @@
+// Package app is the composition root. It turns the loaded configuration
+// into the logger, tracing, storage backend, services, usecases and HTTP
+// handlers, so nothing below it has to look its dependencies up.
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/server"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+)
+
+type App struct {
+	Config *config.Config
+	Store  *store.Store
+	Router *gin.Engine
+
+	lc *lifecycle.Manager
+}
+
+// New builds the application from cfg, the configuration loaded with
+// config.Load. Everything it opens is closed by lc, also when New fails
+// halfway.
+func New(ctx context.Context, cfg *config.Config, lc *lifecycle.Manager) (*App, error) {
+	// --- Initialize Zerolog ---
+	if err := logger.Init(cfg.Log); err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	lc.OnClose("logger", logger.Close)
+	log := logger.Get()
+	log.Info().Msg("Logger initialized")
+
+	watchConfig()
+
+	// --- Initialize tracing; closed last so the final spans are flushed ---
+	shutdownTracing, err := tracing.Init(ctx, cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracing: %w", err)
+	}
+	lc.OnClose("tracing", func() error {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(flushCtx)
+	})
+
+	// --- Initialize Database ---
+	repos, err := store.Open(ctx, cfg.Database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	lc.OnClose("database", repos.Close)
+	log.Info().Str("driver", repos.Driver).Msg("Database connected")
+
+	// --- Setup Gin ---
+	gin.SetMode(cfg.Server.Mode)
+	r := gin.New()
+	r.Use(middleware.RequestLogger())
+	if err := Routes(r, cfg, repos, lc); err != nil {
+		return nil, err
+	}
+
+	return &App{Config: cfg, Store: repos, Router: r, lc: lc}, nil
+}
+
+// Run serves until ctx is cancelled, then drains requests and runs the
+// closers of the lifecycle manager.
+func (a *App) Run(ctx context.Context) error {
+	return server.New(a.Config.Server, a.Router, a.lc).Run(ctx)
+}
+
+// watchConfig applies config file changes while running.
+func watchConfig() {
+	log := logger.Get()
+	config.OnChange(func(c *config.Config) string { return c.Log.Level }, func(level string) {
+		if err := logger.SetLevel(level); err != nil {
+			log.Warn().Err(err).Msg("Config reload: invalid log level")
+			return
+		}
+		log.Info().Str("level", level).Msg("Config reload: log level changed")
+	})
+	if err := config.Watch(func(rejected []string, err error) {
+		if err != nil {
+			log.Warn().Err(err).Msg("Config reload: rejected, keeping the running configuration")
+			return
+		}
+		if len(rejected) > 0 {
+			log.Warn().Strs("keys", rejected).Msg("Config reload: changes require a restart and were ignored")
+		}
+		log.Info().Msg("Config reload: applied")
+	}); err != nil {
+		log.Info().Err(err).Msg("Config reload: disabled")
+	}
+}
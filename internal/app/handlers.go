@@ -0,0 +1,132 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/health"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/metrics"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
+	httpInterface "github.com/luthfiarsyad/mms/internal/interface/http"
+	"github.com/luthfiarsyad/mms/internal/interface/http/handler"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+)
+
+// Routes serves the API from repos on r. Besides the handlers it exports the
+// pool metrics and starts the replica health checks.
+func Routes(r *gin.Engine, cfg *config.Config, repos *store.Store, lc *lifecycle.Manager) error {
+	h, err := NewHandlers(cfg, repos, lc)
+	if err != nil {
+		return err
+	}
+	if cfg.Metrics.Enabled {
+		metrics.ObserveDB(repos.DB)
+	}
+	checkEvery := time.Duration(cfg.Database.ReplicaCheckIntervalSeconds) * time.Second
+	lc.Go("replica health", func(ctx context.Context) { repos.Run(ctx, checkEvery) })
+
+	return httpInterface.SetupRoutes(r, cfg, h, lc)
+}
+
+// NewHandlers builds the services, usecases and handlers over repos. The
+// trash and idempotency key purges run as workers of lc.
+func NewHandlers(cfg *config.Config, repos *store.Store, lc *lifecycle.Manager) (*httpInterface.Handlers, error) {
+	pas, err := security.NewPasetoService(cfg.Paseto)
+	if err != nil {
+		return nil, err
+	}
+	hasher, err := security.NewPasswordHasher(cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password hasher: %w", err)
+	}
+	policy, err := security.NewPasswordPolicy(cfg.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create password policy: %w", err)
+	}
+	lc.OnClose("password policy", policy.Close)
+	checks, err := newHealthChecks(repos, cfg.Health)
+	if err != nil {
+		return nil, err
+	}
+
+	auditService := audit.NewService(repos.Audit, repos.Transactor)
+	audits := usecase.NewAuditUsecase(auditService)
+	sessionService := session.NewService(repos.Sessions)
+	sessions := usecase.NewSessionUsecase(sessionService, cfg.Session)
+	apiKeys := usecase.NewAPIKeyUsecase(apikey.NewService(repos.APIKeys))
+	transactions := usecase.NewTransactionUsecase(transaction.NewService(repos.Transactions, auditService))
+	if days := cfg.Trash.RetentionDays; days > 0 {
+		retention := time.Duration(days) * 24 * time.Hour
+		purgeEvery := time.Duration(cfg.Trash.PurgeIntervalMinutes) * time.Minute
+		lc.Go("trash purge", func(ctx context.Context) { transactions.RunTrashPurge(ctx, retention, purgeEvery) })
+	}
+
+	idempotencyTTL := time.Duration(cfg.Idempotency.TTLHours) * time.Hour
+	idempotencyLock := time.Duration(cfg.Idempotency.LockTimeoutSeconds) * time.Second
+	idempotent := usecase.NewIdempotencyUsecase(idempotency.NewService(repos.Idempotency, idempotencyTTL, idempotencyLock))
+	idempotencyPurgeEvery := time.Duration(cfg.Idempotency.PurgeIntervalMinutes) * time.Minute
+	if idempotencyPurgeEvery == 0 {
+		idempotencyPurgeEvery = time.Hour
+	}
+	lc.Go("idempotency purge", func(ctx context.Context) { idempotent.RunPurge(ctx, idempotencyPurgeEvery) })
+
+	auth := usecase.NewAuthUsecase(user.NewService(repos.Users, auditService), sessionService, pas, hasher, policy)
+	auth.SetTokenTTL(tokenTTL(cfg.Paseto.ExpireMinutes))
+	stopTTL := config.OnChange(func(c *config.Config) int { return c.Paseto.ExpireMinutes }, func(minutes int) {
+		auth.SetTokenTTL(tokenTTL(minutes))
+	})
+	lc.OnClose("token ttl subscriber", func() error {
+		stopTTL()
+		return nil
+	})
+
+	return &httpInterface.Handlers{
+		Health:       handler.NewHealthHandler(checks, lc),
+		Admin:        handler.NewAdminHandler(audits, auth),
+		Auth:         handler.NewAuthHandler(auth),
+		APIKeys:      handler.NewAPIKeyHandler(apiKeys),
+		Sessions:     handler.NewSessionHandler(sessions),
+		Transactions: handler.NewTransactionHandler(transactions),
+		Audit:        handler.NewAuditHandler(audits),
+		RequireAuth:  middleware.AuthMiddleware(pas, apiKeys, sessions),
+		Idempotency:  middleware.Idempotency(idempotent, int64(cfg.Idempotency.MaxBodyKB)<<10),
+	}, nil
+}
+
+// tokenTTL converts paseto.expire_minutes.
+func tokenTTL(minutes int) time.Duration {
+	return time.Duration(minutes) * time.Minute
+}
+
+// newHealthChecks registers the dependencies /readyz depends on. The memory
+// backend has none.
+func newHealthChecks(repos *store.Store, cfg config.HealthConfig) (*health.Registry, error) {
+	checks := health.NewRegistry(
+		time.Duration(cfg.CacheTTLSeconds)*time.Second,
+		time.Duration(cfg.TimeoutSeconds)*time.Second,
+	)
+	if repos.DB == nil {
+		return checks, nil
+	}
+
+	expected, err := repos.ExpectedSchemaVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	checks.Register("database", health.DBPing(repos.DB))
+	checks.Register("schema_version", health.SchemaVersion(repos.SchemaVersion, expected))
+	checks.Register("connection_pool", health.PoolSaturation(repos.DB, cfg.PoolSaturation))
+	return checks, nil
+}
@@ -0,0 +1,16 @@
+package apikey
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, k *APIKey) error
+	FindByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	FindByUserID(ctx context.Context, userID int64) ([]*APIKey, error)
+	// Revoke marks the key of userID as revoked; it returns sql.ErrNoRows
+	// if no active key with that id belongs to the user.
+	Revoke(ctx context.Context, id, userID int64, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int64, at time.Time) error
+}
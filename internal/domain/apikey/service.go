@@ -0,0 +1,158 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Raw keys look like mms_<prefix>_<secret>. The prefix is stored in clear
+// for lookup, only a SHA-256 digest of the whole key is stored.
+const (
+	KeyMarker    = "mms_"
+	prefixBytes  = 6
+	secretBytes  = 32
+	prefixLength = prefixBytes * 2
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+	ErrInvalidScope   = errors.New("unknown api key scope")
+	ErrInvalidName    = errors.New("api key name is required")
+	ErrInvalidExpiry  = errors.New("api key expiry must be in the future")
+)
+
+type Service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+func NewService(r Repository) *Service {
+	return &Service{repo: r, now: time.Now}
+}
+
+// IsAPIKey reports whether raw has the shape of an MMS API key, so callers
+// can tell it apart from a PASETO token.
+func IsAPIKey(raw string) bool {
+	return strings.HasPrefix(raw, KeyMarker)
+}
+
+// Create generates a new key for userID. The returned raw key is the only
+// time the secret is available; it cannot be recovered later.
+func (s *Service) Create(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (*APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrInvalidName
+	}
+	if userID <= 0 {
+		return nil, "", errors.New("user ID is required")
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(KnownScopes, scope) {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidScope, scope)
+		}
+	}
+	now := s.now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, "", ErrInvalidExpiry
+	}
+
+	prefix, err := randomHex(prefixBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(secretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	raw := KeyMarker + prefix + "_" + secret
+
+	k := &APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hashKey(raw),
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(scopes))),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(ctx, k); err != nil {
+		return nil, "", err
+	}
+	return k, raw, nil
+}
+
+// Authenticate resolves a raw key to its stored record. Unknown, revoked
+// and expired keys all yield ErrInvalidAPIKey. The use is not recorded; see
+// TouchLastUsed.
+func (s *Service) Authenticate(ctx context.Context, raw string) (*APIKey, error) {
+	prefix, ok := parsePrefix(raw)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	k, err := s.repo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(k.KeyHash), []byte(hashKey(raw))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if !k.Active(s.now()) {
+		return nil, ErrInvalidAPIKey
+	}
+	return k, nil
+}
+
+// TouchLastUsed records that k was used at.
+func (s *Service) TouchLastUsed(ctx context.Context, k *APIKey, at time.Time) error {
+	if err := s.repo.TouchLastUsed(ctx, k.ID, at); err != nil {
+		return err
+	}
+	k.LastUsedAt = &at
+	return nil
+}
+
+func (s *Service) ListByUser(ctx context.Context, userID int64) ([]*APIKey, error) {
+	return s.repo.FindByUserID(ctx, userID)
+}
+
+func (s *Service) Revoke(ctx context.Context, id, userID int64) error {
+	err := s.repo.Revoke(ctx, id, userID, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+func parsePrefix(raw string) (string, bool) {
+	rest, ok := strings.CutPrefix(raw, KeyMarker)
+	if !ok || len(rest) != prefixLength+1+secretBytes*2 || rest[prefixLength] != '_' {
+		return "", false
+	}
+	return rest[:prefixLength], true
+}
+
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,93 @@
+// Package audit records who changed which money data and when. Records are
+// written in the same database transaction as the change, are never updated
+// or deleted, and form a hash chain: each record hashes its fields together
+// with the hash of the record before it, so editing, removing or reordering
+// stored records breaks the chain and is found by Service.Verify.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	// ActionPurge is the permanent deletion of a transaction that stayed in
+	// the trash past its retention.
+	ActionPurge Action = "purge"
+)
+
+// Entity types of the audited tables.
+const (
+	EntityTransaction = "transaction"
+	EntityUser        = "user"
+)
+
+// Record is one audited change. Before and After hold only the fields that
+// changed, as JSON objects; Before is empty for a create.
+type Record struct {
+	ID int64 `db:"id" json:"id"`
+	// ActorID is the user who made the change, or zero when it was not made
+	// by a user.
+	ActorID    int64           `db:"actor_id" json:"actor_id"`
+	Action     Action          `db:"action" json:"action"`
+	EntityType string          `db:"entity_type" json:"entity_type"`
+	EntityID   int64           `db:"entity_id" json:"entity_id"`
+	Before     json.RawMessage `db:"before_data" json:"before,omitempty"`
+	After      json.RawMessage `db:"after_data" json:"after,omitempty"`
+	IP         string          `db:"ip" json:"ip"`
+	RequestID  string          `db:"request_id" json:"request_id"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	PrevHash   string          `db:"prev_hash" json:"prev_hash"`
+	Hash       string          `db:"hash" json:"hash"`
+}
+
+// ComputeHash returns the hex SHA-256 of the record's fields and PrevHash.
+// The ID is left out: it is assigned by the database after hashing, and the
+// chain already fixes the order.
+func (r *Record) ComputeHash() string {
+	b, _ := json.Marshal(struct {
+		PrevHash   string `json:"prev_hash"`
+		ActorID    int64  `json:"actor_id"`
+		Action     Action `json:"action"`
+		EntityType string `json:"entity_type"`
+		EntityID   int64  `json:"entity_id"`
+		Before     string `json:"before"`
+		After      string `json:"after"`
+		IP         string `json:"ip"`
+		RequestID  string `json:"request_id"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		PrevHash:   r.PrevHash,
+		ActorID:    r.ActorID,
+		Action:     r.Action,
+		EntityType: r.EntityType,
+		EntityID:   r.EntityID,
+		Before:     string(r.Before),
+		After:      string(r.After),
+		IP:         r.IP,
+		RequestID:  r.RequestID,
+		CreatedAt:  r.CreatedAt.UTC().Format(time.RFC3339),
+	})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// Filter selects records; zero fields match everything.
+type Filter struct {
+	ActorID    int64
+	EntityType string
+	EntityID   int64
+	// From is inclusive, To exclusive.
+	From, To time.Time
+	// When Limit is positive, Find skips Offset matching records and
+	// returns at most Limit of them. Count ignores both.
+	Limit, Offset int
+}
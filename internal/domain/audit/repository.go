@@ -0,0 +1,28 @@
+package audit
+
+import "context"
+
+// Repository stores the chain. It has no way to change or remove a record;
+// the schema rejects that too.
+type Repository interface {
+	// Head returns the hash of the newest record, or "" when there is none.
+	// Inside a transaction it locks the chain until the transaction ends, so
+	// concurrent writers append one after another.
+	Head(ctx context.Context) (string, error)
+	// Append stores r, setting its ID, and makes r.Hash the head.
+	Append(ctx context.Context, r *Record) error
+	// Find returns the records matching f, newest first.
+	Find(ctx context.Context, f Filter) ([]*Record, error)
+	// Count returns how many records match f.
+	Count(ctx context.Context, f Filter) (int, error)
+	// Chain returns up to limit records with IDs above afterID, oldest
+	// first.
+	Chain(ctx context.Context, afterID int64, limit int) ([]*Record, error)
+}
+
+// Transactor runs fn in a database transaction that commits when fn returns
+// nil and rolls back otherwise. Repositories of the same backend called with
+// the ctx passed to fn take part in it.
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,207 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luthfiarsyad/mms/pkg/actor"
+	"github.com/luthfiarsyad/mms/pkg/clientip"
+	"github.com/luthfiarsyad/mms/pkg/requestid"
+)
+
+// ErrTampered is returned by Verify when the stored chain does not match
+// what was written.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+// verifyBatch is how many records Verify loads at a time.
+const verifyBatch = 500
+
+// Change describes one change to an entity. Before and After are the entity
+// before and after the change, encoded to JSON by Diff; Before is nil for a
+// create.
+type Change struct {
+	Action     Action
+	EntityType string
+	EntityID   int64
+	Before     any
+	After      any
+	// ActorID is used when the request has no authenticated user, e.g. for
+	// the user created by a registration.
+	ActorID int64
+}
+
+type Service struct {
+	repo Repository
+	tx   Transactor
+	now  func() time.Time
+}
+
+func NewService(r Repository, tx Transactor) *Service {
+	return &Service{repo: r, tx: tx, now: time.Now}
+}
+
+// Apply runs change in a transaction and records the Change it returns in
+// the same transaction, so the change and its record are stored together or
+// not at all. Nothing is recorded when change fails or returns nil. The
+// actor, client IP and request ID are taken from ctx.
+func (s *Service) Apply(ctx context.Context, change func(ctx context.Context) (*Change, error)) error {
+	return s.ApplyAll(ctx, func(ctx context.Context) ([]*Change, error) {
+		c, err := change(ctx)
+		if err != nil || c == nil {
+			return nil, err
+		}
+		return []*Change{c}, nil
+	})
+}
+
+// ApplyAll is Apply for a change that touches several entities at once, such
+// as a multi-row insert. Every Change it returns is recorded, in order.
+func (s *Service) ApplyAll(ctx context.Context, change func(ctx context.Context) ([]*Change, error)) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		changes, err := change(ctx)
+		if err != nil {
+			return err
+		}
+		for _, c := range changes {
+			if err := s.record(ctx, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WithinTx runs fn in a transaction. Apply and ApplyAll called from fn join
+// it, so several changes can be stored, and recorded, all or none.
+func (s *Service) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.tx.WithinTx(ctx, fn)
+}
+
+func (s *Service) record(ctx context.Context, c *Change) error {
+	before, after, err := Diff(c.Before, c.After)
+	if err != nil {
+		return err
+	}
+	actorID, ok := actor.UserID(ctx)
+	if !ok {
+		actorID = c.ActorID
+	}
+	r := &Record{
+		ActorID:    actorID,
+		Action:     c.Action,
+		EntityType: c.EntityType,
+		EntityID:   c.EntityID,
+		Before:     before,
+		After:      after,
+		IP:         clientip.FromContext(ctx),
+		RequestID:  requestid.FromContext(ctx),
+		CreatedAt:  s.now().UTC().Truncate(time.Second),
+	}
+	if r.PrevHash, err = s.repo.Head(ctx); err != nil {
+		return fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+	r.Hash = r.ComputeHash()
+	if err := s.repo.Append(ctx, r); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// List returns the records matching f, newest first.
+func (s *Service) List(ctx context.Context, f Filter) ([]*Record, error) {
+	return s.repo.Find(ctx, f)
+}
+
+// Count returns how many records match f.
+func (s *Service) Count(ctx context.Context, f Filter) (int, error) {
+	return s.repo.Count(ctx, f)
+}
+
+// Verify walks the chain from the oldest record and returns how many records
+// it checked. It fails with ErrTampered when a record no longer matches its
+// hash, does not point to the record before it, or when the newest records
+// are gone.
+func (s *Service) Verify(ctx context.Context) (int, error) {
+	// The head is read first: records appended during the walk come after it.
+	head, err := s.repo.Head(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	checked, prev, sawHead := 0, "", head == ""
+	var afterID int64
+	for {
+		records, err := s.repo.Chain(ctx, afterID, verifyBatch)
+		if err != nil {
+			return checked, err
+		}
+		for _, r := range records {
+			if r.PrevHash != prev {
+				return checked, fmt.Errorf("%w: record %d does not follow the record before it", ErrTampered, r.ID)
+			}
+			if r.ComputeHash() != r.Hash {
+				return checked, fmt.Errorf("%w: record %d was modified", ErrTampered, r.ID)
+			}
+			sawHead = sawHead || r.Hash == head
+			prev, afterID = r.Hash, r.ID
+			checked++
+		}
+		if len(records) < verifyBatch {
+			break
+		}
+	}
+	if !sawHead {
+		return checked, fmt.Errorf("%w: records after %d are missing", ErrTampered, afterID)
+	}
+	return checked, nil
+}
+
+// Diff encodes before and after to JSON objects and keeps only the fields
+// that differ. A nil side stays empty and the other side is kept whole.
+func Diff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if b != nil && a != nil {
+		for k, v := range b {
+			if w, ok := a[k]; ok && bytes.Equal(v, w) {
+				delete(b, k)
+				delete(a, k)
+			}
+		}
+	}
+	return encode(b), encode(a), nil
+}
+
+func fields(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audited entity: %w", err)
+	}
+	m := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("audited entity is not a JSON object: %w", err)
+	}
+	return m, nil
+}
+
+// encode marshals m with sorted keys, so equal diffs hash equally.
+func encode(m map[string]json.RawMessage) json.RawMessage {
+	if m == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(m)
+	return raw
+}
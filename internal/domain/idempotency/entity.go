@@ -0,0 +1,37 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// Record remembers a request a user sent with an Idempotency-Key header and,
+// once it has succeeded, the response it got, so that a retry can be
+// answered with that response instead of being run again.
+type Record struct {
+	UserID int64  `db:"user_id"`
+	Key    string `db:"idempotency_key"`
+	// RequestHash identifies the request the key was first used with; see
+	// HashRequest.
+	RequestHash string `db:"request_hash"`
+	// Status is 0 while the first request is still running.
+	Status    int               `db:"status"`
+	Header    map[string]string `db:"response_headers"`
+	Body      []byte            `db:"response_body"`
+	CreatedAt time.Time         `db:"created_at"`
+	ExpiresAt time.Time         `db:"expires_at"`
+}
+
+// Completed reports whether the response has been stored.
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+// HashRequest identifies a request by its method, path and body.
+func HashRequest(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
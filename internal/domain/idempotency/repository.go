@@ -0,0 +1,27 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Repository stores one record per user and key.
+type Repository interface {
+	// Create stores r as in progress. It returns ErrKeyExists when the user
+	// already has a record with r.Key; the uniqueness is enforced by the
+	// database, so of two concurrent requests with one key only one wins.
+	Create(ctx context.Context, r *Record) error
+	// Find returns sql.ErrNoRows when the user has no record with key.
+	Find(ctx context.Context, userID int64, key string) (*Record, error)
+	// Complete stores the status, header and body of r.
+	Complete(ctx context.Context, r *Record) error
+	// Delete removes the record so the key can be used again.
+	Delete(ctx context.Context, userID int64, key string) error
+	// DeleteAbandoned removes the record only if it is still in progress
+	// and was created before startedBefore, so that a record claimed again
+	// in the meantime is kept.
+	DeleteAbandoned(ctx context.Context, userID int64, key string, startedBefore time.Time) error
+	// Purge deletes the records that expired before cutoff and returns how
+	// many there were.
+	Purge(ctx context.Context, cutoff time.Time) (int64, error)
+}
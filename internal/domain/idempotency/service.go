@@ -0,0 +1,116 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	// MaxKeyLength is the longest Idempotency-Key accepted.
+	MaxKeyLength = 255
+	// DefaultTTL is how long responses are kept when NewService gets a zero
+	// ttl.
+	DefaultTTL = 24 * time.Hour
+	// DefaultLockTimeout is how long a request may hold its key when
+	// NewService gets a zero lockTimeout.
+	DefaultLockTimeout = time.Minute
+)
+
+var (
+	// ErrKeyExists is returned by repositories when a key is already stored.
+	ErrKeyExists  = errors.New("idempotency key already exists")
+	ErrInvalidKey = errors.New("idempotency key must be 1 to 255 characters")
+	ErrKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+// Service keeps the responses of idempotent requests for ttl. A key still
+// in progress after lockTimeout belongs to a request that was lost, e.g.
+// because the process died, and is claimed by the next request with it.
+type Service struct {
+	repo        Repository
+	ttl         time.Duration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+func NewService(r Repository, ttl, lockTimeout time.Duration) *Service {
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if lockTimeout == 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+	return &Service{repo: r, ttl: ttl, lockTimeout: lockTimeout, now: time.Now}
+}
+
+// Begin claims key for the request identified by hash. A nil record means
+// the caller should run the request and then Complete or Release the key.
+// Otherwise the request already succeeded and the record holds the response
+// to replay. It returns ErrKeyReused when the key was used for a different
+// request and ErrInProgress while the first request is still running, for
+// at most lockTimeout.
+func (s *Service) Begin(ctx context.Context, userID int64, key, hash string) (*Record, error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, ErrInvalidKey
+	}
+	now := s.now()
+	r := &Record{UserID: userID, Key: key, RequestHash: hash, CreatedAt: now, ExpiresAt: now.Add(s.ttl)}
+	// A second attempt is needed when the stored record has expired, was
+	// abandoned or disappears between Create and Find.
+	for attempt := 0; attempt < 2; attempt++ {
+		err := s.repo.Create(ctx, r)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, ErrKeyExists) {
+			return nil, err
+		}
+		existing, err := s.repo.Find(ctx, userID, key)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !now.Before(existing.ExpiresAt) {
+			if _, err := s.repo.Purge(ctx, now); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if startedBefore := now.Add(-s.lockTimeout); !existing.Completed() && existing.CreatedAt.Before(startedBefore) {
+			// Like a released key, an abandoned one may be used for a
+			// different request.
+			if err := s.repo.DeleteAbandoned(ctx, userID, key, startedBefore); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if existing.RequestHash != hash {
+			return nil, ErrKeyReused
+		}
+		if !existing.Completed() {
+			return nil, ErrInProgress
+		}
+		return existing, nil
+	}
+	return nil, ErrInProgress
+}
+
+// Complete stores the response of the request that claimed key with Begin.
+func (s *Service) Complete(ctx context.Context, userID int64, key string, status int, header map[string]string, body []byte) error {
+	return s.repo.Complete(ctx, &Record{UserID: userID, Key: key, Status: status, Header: header, Body: body})
+}
+
+// Release gives up key after its request failed, so a retry runs it again.
+func (s *Service) Release(ctx context.Context, userID int64, key string) error {
+	return s.repo.Delete(ctx, userID, key)
+}
+
+// Purge deletes the records that have expired.
+func (s *Service) Purge(ctx context.Context) (int64, error) {
+	return s.repo.Purge(ctx, s.now())
+}
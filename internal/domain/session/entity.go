@@ -0,0 +1,21 @@
+package session
+
+import "time"
+
+// Session is one login of a user, referenced by the session ID embedded in
+// the PASETO token issued at login.
+type Session struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     int64      `db:"user_id" json:"-"`
+	IP         string     `db:"ip" json:"ip"`
+	UserAgent  string     `db:"user_agent" json:"user_agent"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	LastSeenAt time.Time  `db:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"-"`
+}
+
+// Active reports whether the session is neither revoked nor expired at now.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,18 @@
+package session
+
+import (
+	"context"
+	"time"
+)
+
+type Repository interface {
+	Create(ctx context.Context, s *Session) error
+	FindByID(ctx context.Context, id string) (*Session, error)
+	// FindActiveByUserID returns sessions that are not revoked and not
+	// expired at now, most recently seen first.
+	FindActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]*Session, error)
+	// Revoke returns sql.ErrNoRows if no active session with that id
+	// belongs to the user.
+	Revoke(ctx context.Context, id string, userID int64, at time.Time) error
+	TouchLastSeen(ctx context.Context, id string, at time.Time) error
+}
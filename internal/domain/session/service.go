@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	maxUserAgentLength = 255
+	idBytes            = 16
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionInactive = errors.New("session is revoked or expired")
+)
+
+type Service struct {
+	repo Repository
+	now  func() time.Time
+}
+
+func NewService(r Repository) *Service {
+	return &Service{repo: r, now: time.Now}
+}
+
+// Start records a new session for userID that expires after ttl.
+func (s *Service) Start(ctx context.Context, userID int64, ip, userAgent string, ttl time.Duration) (*Session, error) {
+	if userID <= 0 {
+		return nil, errors.New("user ID is required")
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	now := s.now()
+	sess := &Session{
+		ID:         id,
+		UserID:     userID,
+		IP:         ip,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+	}
+	if err := s.repo.Create(ctx, sess); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
+
+// Validate returns the session if it belongs to userID and is still active.
+func (s *Service) Validate(ctx context.Context, id string, userID int64) (*Session, error) {
+	sess, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+	if sess.UserID != userID {
+		return nil, ErrSessionNotFound
+	}
+	if !sess.Active(s.now()) {
+		return nil, ErrSessionInactive
+	}
+	return sess, nil
+}
+
+func (s *Service) ListActive(ctx context.Context, userID int64) ([]*Session, error) {
+	return s.repo.FindActiveByUserID(ctx, userID, s.now())
+}
+
+func (s *Service) Revoke(ctx context.Context, id string, userID int64) error {
+	err := s.repo.Revoke(ctx, id, userID, s.now())
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+func (s *Service) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	return s.repo.TouchLastSeen(ctx, id, at)
+}
+
+func newID() (string, error) {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
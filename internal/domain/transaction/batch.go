@@ -0,0 +1,201 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+)
+
+// MaxBatchSize is the most operations a single batch may hold.
+const MaxBatchSize = 100
+
+var (
+	ErrBatchTooLarge    = fmt.Errorf("a batch holds at most %d operations", MaxBatchSize)
+	ErrInvalidOperation = errors.New("operation must be 'create', 'update' or 'delete'")
+	// ErrBatchAborted is the result of the operations of an atomic batch
+	// that were rolled back, or never run, because another one failed.
+	ErrBatchAborted = errors.New("batch was rolled back because another operation failed")
+)
+
+// OpKind says what an Operation does.
+type OpKind string
+
+const (
+	OpCreate OpKind = "create"
+	OpUpdate OpKind = "update"
+	OpDelete OpKind = "delete"
+)
+
+// Operation is one item of a batch. A create takes all of Fields; an update
+// applies Fields to transaction ID if it is still at Version; a delete moves
+// transaction ID to the trash.
+type Operation struct {
+	Kind    OpKind
+	ID      int64
+	Version int64
+	Fields  Patch
+}
+
+// Result is the outcome of one Operation: the created or updated
+// transaction, or why the operation failed. Deletes return no transaction.
+type Result struct {
+	Transaction *Transaction
+	Err         error
+}
+
+// Batch runs ops for the user, in order, and returns one Result per
+// operation. Transactions of other users are reported as not found.
+//
+// An atomic batch runs in one database transaction and stops at the first
+// failure: that operation keeps its error, every other one gets
+// ErrBatchAborted, nothing is stored and Batch returns ErrBatchAborted.
+// Otherwise every operation succeeds or fails on its own. Either way,
+// adjacent creates are stored with one multi-row insert; outside an atomic
+// batch they are retried one by one if that insert fails.
+func (s *Service) Batch(ctx context.Context, userID int64, ops []Operation, atomic bool) ([]Result, error) {
+	if len(ops) > MaxBatchSize {
+		return nil, ErrBatchTooLarge
+	}
+	results := make([]Result, len(ops))
+	if !atomic {
+		_ = s.runBatch(ctx, userID, ops, results, false)
+		return results, nil
+	}
+
+	failed := false
+	err := s.audits.WithinTx(ctx, func(ctx context.Context) error {
+		err := s.runBatch(ctx, userID, ops, results, true)
+		failed = err != nil
+		return err
+	})
+	if err == nil {
+		return results, nil
+	}
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = Result{Err: ErrBatchAborted}
+		} else {
+			results[i].Transaction = nil
+		}
+	}
+	if !failed {
+		// Every operation succeeded, but the commit did not.
+		return results, fmt.Errorf("%w: %w", ErrBatchAborted, err)
+	}
+	return results, ErrBatchAborted
+}
+
+// runBatch fills in results. In atomic mode it returns the first error and
+// leaves the remaining operations unrun.
+func (s *Service) runBatch(ctx context.Context, userID int64, ops []Operation, results []Result, atomic bool) error {
+	for i := 0; i < len(ops); {
+		n := 1
+		var err error
+		switch ops[i].Kind {
+		case OpCreate:
+			for i+n < len(ops) && ops[i+n].Kind == OpCreate {
+				n++
+			}
+			err = s.createMany(ctx, userID, ops[i:i+n], results[i:i+n])
+			if err != nil && !atomic && n > 1 {
+				// Find out which of the creates failed.
+				for j := i; j < i+n; j++ {
+					results[j] = Result{}
+					_ = s.createMany(ctx, userID, ops[j:j+1], results[j:j+1])
+				}
+			}
+		case OpUpdate:
+			var t *Transaction
+			t, err = s.updateOwned(ctx, userID, ops[i])
+			results[i] = Result{Transaction: t, Err: err}
+		case OpDelete:
+			err = s.deleteOwned(ctx, userID, ops[i].ID)
+			results[i].Err = err
+		default:
+			err = ErrInvalidOperation
+			results[i].Err = err
+		}
+		if err != nil && atomic {
+			return err
+		}
+		i += n
+	}
+	return nil
+}
+
+// createMany validates the creates in ops, stores them with one insert and
+// records each of them in the audit log. It fills in results and returns
+// the first error.
+func (s *Service) createMany(ctx context.Context, userID int64, ops []Operation, results []Result) error {
+	now := time.Now()
+	ts := make([]*Transaction, len(ops))
+	for i, op := range ops {
+		t := &Transaction{UserID: userID, CreatedAt: now, UpdatedAt: now}
+		if op.Fields.Amount != nil {
+			t.Amount = *op.Fields.Amount
+		}
+		if op.Fields.Description != nil {
+			t.Description = *op.Fields.Description
+		}
+		if op.Fields.Type != nil {
+			t.Type = *op.Fields.Type
+		}
+		if err := validate(t); err != nil {
+			results[i].Err = err
+			return err
+		}
+		ts[i] = t
+	}
+
+	err := s.audits.ApplyAll(ctx, func(ctx context.Context) ([]*audit.Change, error) {
+		if err := s.repo.CreateMany(ctx, ts); err != nil {
+			return nil, err
+		}
+		changes := make([]*audit.Change, len(ts))
+		for i, t := range ts {
+			changes[i] = &audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityTransaction, EntityID: t.ID, After: t}
+		}
+		return changes, nil
+	})
+	for i, t := range ts {
+		if err != nil {
+			results[i].Err = err
+		} else {
+			results[i].Transaction = t
+		}
+	}
+	return err
+}
+
+func (s *Service) updateOwned(ctx context.Context, userID int64, op Operation) (*Transaction, error) {
+	if op.Fields.Empty() {
+		return nil, ErrEmptyPatch
+	}
+	if err := s.checkOwner(ctx, userID, op.ID); err != nil {
+		return nil, err
+	}
+	return s.Update(ctx, op.ID, op.Version, op.Fields)
+}
+
+func (s *Service) deleteOwned(ctx context.Context, userID, id int64) error {
+	if err := s.checkOwner(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.Delete(ctx, id)
+}
+
+// checkOwner reports other users' transactions as not found, so their
+// existence is not leaked.
+func (s *Service) checkOwner(ctx context.Context, userID, id int64) error {
+	t, err := s.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if t.UserID != userID {
+		return ErrTransactionNotFound
+	}
+	return nil
+}
@@ -12,6 +12,23 @@ type Transaction struct {
 	Type        string    `db:"type" json:"type"` // "income" or "expense"
 	CreatedAt   time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+	// DeletedAt is set while the transaction is in the trash.
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+	// Version starts at 1 and goes up with every update, so an edit made
+	// from a stale copy can be detected.
+	Version int64 `db:"version" json:"version"`
+}
+
+// Patch holds the fields of an update; nil fields are left as they are.
+type Patch struct {
+	Amount      *float64
+	Description *string
+	Type        *string
+}
+
+// Empty reports whether p changes nothing.
+func (p Patch) Empty() bool {
+	return p.Amount == nil && p.Description == nil && p.Type == nil
 }
 
 type TransactionType string
@@ -2,14 +2,51 @@ package transaction
 
 import (
 	"context"
+	"time"
 )
 
+// Page selects part of a list: Find methods skip Offset items and return at
+// most Limit. A zero Limit selects the whole list.
+type Page struct {
+	Limit, Offset int
+}
+
+// Repository stores transactions. Deleted transactions stay in the trash
+// until purged; only the methods named after deleted transactions, Restore
+// and Purge see them.
 type Repository interface {
+	// Create stores t at version 1.
 	Create(ctx context.Context, t *Transaction) error
+	// CreateMany stores ts with a single multi-row insert, each at version
+	// 1, and sets their IDs. Either all of them are stored or none.
+	CreateMany(ctx context.Context, ts []*Transaction) error
 	FindByID(ctx context.Context, id int64) (*Transaction, error)
-	FindByUserID(ctx context.Context, userID int64) ([]*Transaction, error)
+	// FindByUserID lists the user's transactions, newest first.
+	FindByUserID(ctx context.Context, userID int64, page Page) ([]*Transaction, error)
+	// CountByUserID returns how many transactions the user has.
+	CountByUserID(ctx context.Context, userID int64) (int, error)
+	// Update stores t if the stored transaction is still at t.Version, and
+	// then increments t.Version. It returns sql.ErrNoRows when it is not,
+	// or when the transaction is missing or in the trash.
 	Update(ctx context.Context, t *Transaction) error
-	Delete(ctx context.Context, id int64) error
+	// Delete moves the transaction to the trash.
+	Delete(ctx context.Context, id int64, at time.Time) error
+	// FindDeletedByUserID lists the user's trash, most recently deleted first.
+	FindDeletedByUserID(ctx context.Context, userID int64, page Page) ([]*Transaction, error)
+	// CountDeletedByUserID returns how many transactions are in the user's
+	// trash.
+	CountDeletedByUserID(ctx context.Context, userID int64) (int, error)
+	// FindDeletedByID returns the user's transaction from the trash. It
+	// returns sql.ErrNoRows when the user has no such transaction in the
+	// trash.
+	FindDeletedByID(ctx context.Context, id, userID int64) (*Transaction, error)
+	// Restore takes the user's transaction out of the trash. It returns
+	// sql.ErrNoRows when the user has no such transaction in the trash.
+	Restore(ctx context.Context, id, userID int64) error
+	// Purge permanently deletes up to limit transactions trashed before
+	// cutoff and returns them. Call it in a transaction, so the rows it
+	// returns are the rows it deleted.
+	Purge(ctx context.Context, cutoff time.Time, limit int) ([]*Transaction, error)
 }
 
 type TxRepository interface {
@@ -2,67 +2,217 @@ package transaction
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"time"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
 )
 
 var (
 	ErrTransactionNotFound = errors.New("transaction not found")
 	ErrInvalidAmount       = errors.New("amount must be greater than 0")
 	ErrInvalidType         = errors.New("transaction type must be 'income' or 'expense'")
+	// ErrVersionConflict means the transaction changed since the version the
+	// caller based its update on.
+	ErrVersionConflict = errors.New("transaction was modified by another request")
+	ErrEmptyPatch      = errors.New("at least one field must be given")
 )
 
+// Service records every create, update, delete and restore in the audit log,
+// in the same database transaction as the change.
 type Service struct {
-	repo Repository
+	repo   Repository
+	audits *audit.Service
 }
 
-func NewService(r Repository) *Service {
-	return &Service{repo: r}
+func NewService(r Repository, audits *audit.Service) *Service {
+	return &Service{repo: r, audits: audits}
 }
 
 func (s *Service) Create(ctx context.Context, t *Transaction) error {
-	// Validate transaction
-	if t.Amount <= 0 {
-		return ErrInvalidAmount
+	if err := validate(t); err != nil {
+		return err
 	}
-	
-	if t.Type != string(TransactionTypeIncome) && t.Type != string(TransactionTypeExpense) {
-		return ErrInvalidType
-	}
-	
+
 	if t.UserID <= 0 {
 		return errors.New("user ID is required")
 	}
-	
+
 	t.CreatedAt = time.Now()
 	t.UpdatedAt = time.Now()
-	
-	return s.repo.Create(ctx, t)
-}
 
-func (s *Service) GetByID(ctx context.Context, id int64) (*Transaction, error) {
-	return s.repo.FindByID(ctx, id)
-}
-
-func (s *Service) GetByUserID(ctx context.Context, userID int64) ([]*Transaction, error) {
-	return s.repo.FindByUserID(ctx, userID)
+	return s.audits.Apply(ctx, func(ctx context.Context) (*audit.Change, error) {
+		if err := s.repo.Create(ctx, t); err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityTransaction, EntityID: t.ID, After: t}, nil
+	})
 }
 
-func (s *Service) Update(ctx context.Context, t *Transaction) error {
-	// Validate transaction
+// validate checks the fields a client sets.
+func validate(t *Transaction) error {
 	if t.Amount <= 0 {
 		return ErrInvalidAmount
 	}
-	
 	if t.Type != string(TransactionTypeIncome) && t.Type != string(TransactionTypeExpense) {
 		return ErrInvalidType
 	}
-	
-	t.UpdatedAt = time.Now()
-	
-	return s.repo.Update(ctx, t)
+	return nil
+}
+
+func (s *Service) GetByID(ctx context.Context, id int64) (*Transaction, error) {
+	t, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetByUserID returns one page of the user's transactions, newest first,
+// and how many the user has in total.
+func (s *Service) GetByUserID(ctx context.Context, userID int64, page Page) ([]*Transaction, int, error) {
+	transactions, err := s.repo.FindByUserID(ctx, userID, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
 }
 
+// Update applies p to the transaction if it is still at version and returns
+// the result. It returns ErrVersionConflict when someone else updated the
+// transaction first.
+func (s *Service) Update(ctx context.Context, id, version int64, p Patch) (*Transaction, error) {
+	var after Transaction
+	err := s.audits.Apply(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if before.Version != version {
+			return nil, ErrVersionConflict
+		}
+		after = *before
+		if p.Amount != nil {
+			after.Amount = *p.Amount
+		}
+		if p.Description != nil {
+			after.Description = *p.Description
+		}
+		if p.Type != nil {
+			after.Type = *p.Type
+		}
+		if err := validate(&after); err != nil {
+			return nil, err
+		}
+		after.UpdatedAt = time.Now()
+
+		if err := s.repo.Update(ctx, &after); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrVersionConflict
+			}
+			return nil, err
+		}
+		return &audit.Change{Action: audit.ActionUpdate, EntityType: audit.EntityTransaction, EntityID: id, Before: before, After: &after}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// Delete moves the transaction to the trash, from where it can be restored
+// until it is purged.
 func (s *Service) Delete(ctx context.Context, id int64) error {
-	return s.repo.Delete(ctx, id)
-}
\ No newline at end of file
+	return s.audits.Apply(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		at := time.Now()
+		if err := s.repo.Delete(ctx, id, at); err != nil {
+			return nil, err
+		}
+		after := *before
+		after.DeletedAt = &at
+		return &audit.Change{Action: audit.ActionDelete, EntityType: audit.EntityTransaction, EntityID: id, Before: before, After: &after}, nil
+	})
+}
+
+// Trash returns one page of the user's deleted transactions, most recently
+// deleted first, and how many are in the trash.
+func (s *Service) Trash(ctx context.Context, userID int64, page Page) ([]*Transaction, int, error) {
+	transactions, err := s.repo.FindDeletedByUserID(ctx, userID, page)
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := s.repo.CountDeletedByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// Restore takes the user's transaction out of the trash.
+func (s *Service) Restore(ctx context.Context, id, userID int64) error {
+	return s.audits.Apply(ctx, func(ctx context.Context) (*audit.Change, error) {
+		before, err := s.repo.FindDeletedByID(ctx, id, userID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrTransactionNotFound
+			}
+			return nil, err
+		}
+		if err := s.repo.Restore(ctx, id, userID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, ErrTransactionNotFound
+			}
+			return nil, err
+		}
+		after := *before
+		after.DeletedAt = nil
+		return &audit.Change{Action: audit.ActionRestore, EntityType: audit.EntityTransaction, EntityID: id, Before: before, After: &after}, nil
+	})
+}
+
+// purgeBatch is how many transactions Purge deletes, and records, per
+// database transaction.
+const purgeBatch = 500
+
+// Purge permanently deletes the transactions that have been in the trash
+// for longer than retention. Every purged transaction is recorded in the
+// audit log with its last contents; the records have no actor, as no user
+// made the change.
+func (s *Service) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-retention)
+	var total int64
+	for {
+		n := 0
+		err := s.audits.ApplyAll(ctx, func(ctx context.Context) ([]*audit.Change, error) {
+			purged, err := s.repo.Purge(ctx, cutoff, purgeBatch)
+			if err != nil {
+				return nil, err
+			}
+			n = len(purged)
+			changes := make([]*audit.Change, len(purged))
+			for i, t := range purged {
+				changes[i] = &audit.Change{Action: audit.ActionPurge, EntityType: audit.EntityTransaction, EntityID: t.ID, Before: t}
+			}
+			return changes, nil
+		})
+		if err != nil {
+			return total, err
+		}
+		total += int64(n)
+		if n < purgeBatch {
+			return total, nil
+		}
+	}
+}
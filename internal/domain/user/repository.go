@@ -8,6 +8,7 @@ type Repository interface {
 	Create(ctx context.Context, u *User) error
 	FindByEmail(ctx context.Context, email string) (*User, error)
 	FindByID(ctx context.Context, id int64) (*User, error)
+	UpdatePassword(ctx context.Context, id int64, password string) error
 }
 
 // TxRepository is optional if you use transactions
@@ -5,25 +5,39 @@ import (
 	"database/sql"
 	"errors"
 	"time"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
 )
 
 var (
 	ErrUserNotFound = errors.New("user not found")
 	ErrInvalidCreds = errors.New("invalid credentials")
+	ErrEmailTaken   = errors.New("email is already registered")
 )
 
+// Service records registrations and password changes in the audit log.
 type Service struct {
-	repo Repository
+	repo   Repository
+	audits *audit.Service
+}
+
+func NewService(r Repository, audits *audit.Service) *Service {
+	return &Service{repo: r, audits: audits}
 }
 
-func NewService(r Repository) *Service { return &Service{repo: r} }
+// Register stores u; the new user is the actor of the audit record.
 func (s *Service) Register(ctx context.Context, u *User) error {
 	// Set created_at if not already set
 	if u.CreatedAt.IsZero() {
 		u.CreatedAt = time.Now()
 	}
-	// repository should check unique email; we keep simple here
-	return s.repo.Create(ctx, u)
+	return s.audits.Apply(ctx, func(ctx context.Context) (*audit.Change, error) {
+		// repository should check unique email; we keep simple here
+		if err := s.repo.Create(ctx, u); err != nil {
+			return nil, err
+		}
+		return &audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityUser, EntityID: u.ID, After: u, ActorID: u.ID}, nil
+	})
 }
 func (s *Service) Authenticate(ctx context.Context, email, password string) (*User, error) {
 	u, err := s.repo.FindByEmail(ctx, email)
@@ -35,3 +49,42 @@ func (s *Service) Authenticate(ctx context.Context, email, password string) (*Us
 	}
 	return u, nil
 }
+
+func (s *Service) GetByID(ctx context.Context, id int64) (*User, error) {
+	u, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+// ChangePassword stores the password hash the user chose. The audit record
+// notes the change but never holds either hash.
+func (s *Service) ChangePassword(ctx context.Context, id int64, hashedPassword string) error {
+	return s.audits.Apply(ctx, func(ctx context.Context) (*audit.Change, error) {
+		if err := s.repo.UpdatePassword(ctx, id, hashedPassword); err != nil {
+			return nil, err
+		}
+		return &audit.Change{
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityUser,
+			EntityID:   id,
+			Before:     passwordField{Password: "[redacted]"},
+			After:      passwordField{Password: "[changed]"},
+		}, nil
+	})
+}
+
+type passwordField struct {
+	Password string `json:"password"`
+}
+
+// UpdatePassword stores a new hash of the same password, e.g. when it is
+// upgraded to current hashing parameters. That is not a change the user
+// made, so it is not audited.
+func (s *Service) UpdatePassword(ctx context.Context, id int64, hashedPassword string) error {
+	return s.repo.UpdatePassword(ctx, id, hashedPassword)
+}
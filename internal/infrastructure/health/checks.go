@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DefaultPoolSaturation is the share of open connections in use above which
+// the pool is reported as saturated.
+const DefaultPoolSaturation = 0.9
+
+// DBPing checks that the database answers within the check timeout.
+func DBPing(db *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// SchemaVersion checks that the database schema is at the version the code
+// was built for, catching deploys that skipped or are ahead of migrations.
+func SchemaVersion(current func(ctx context.Context) (int, error), expected int) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		v, err := current(ctx)
+		if err != nil {
+			return err
+		}
+		if v != expected {
+			return fmt.Errorf("schema version is %d, expected %d", v, expected)
+		}
+		return nil
+	})
+}
+
+// PoolSaturation fails when at least threshold of the maximum number of open
+// connections are in use. Unlimited pools never saturate.
+func PoolSaturation(db *sql.DB, threshold float64) Checker {
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultPoolSaturation
+	}
+	return CheckerFunc(func(context.Context) error {
+		stats := db.Stats()
+		if stats.MaxOpenConnections == 0 {
+			return nil
+		}
+		used := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		if used >= threshold {
+			return fmt.Errorf("%d of %d connections in use", stats.InUse, stats.MaxOpenConnections)
+		}
+		return nil
+	})
+}
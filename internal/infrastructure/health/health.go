@@ -0,0 +1,130 @@
+// Package health runs the readiness checks behind /readyz. Checks are
+// registered by name and run concurrently; results are cached for a short
+// time so frequent probes do not hammer dependencies.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	DefaultCacheTTL = 2 * time.Second
+	DefaultTimeout  = 2 * time.Second
+)
+
+// Checker reports whether a dependency is usable. It should respect ctx,
+// which carries the per-check timeout.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error { return f(ctx) }
+
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status    string    `json:"status"`
+	CheckedAt time.Time `json:"checked_at"`
+	Checks    []Result  `json:"checks"`
+}
+
+func (r Report) OK() bool { return r.Status == StatusOK }
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+type Registry struct {
+	cacheTTL time.Duration
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	checks []namedChecker
+	last   *Report
+}
+
+// NewRegistry returns an empty registry. Zero durations use the defaults.
+func NewRegistry(cacheTTL, timeout time.Duration) *Registry {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Registry{cacheTTL: cacheTTL, timeout: timeout}
+}
+
+// Register adds a check. Checks run in registration order in reports.
+func (r *Registry) Register(name string, c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, namedChecker{name: name, checker: c})
+	r.last = nil
+}
+
+// Run returns the cached report if it is fresh enough, otherwise runs every
+// check. Concurrent callers share a single run.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.last != nil && time.Since(r.last.CheckedAt) < r.cacheTTL {
+		return *r.last
+	}
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now(),
+		Checks:    make([]Result, len(r.checks)),
+	}
+	var wg sync.WaitGroup
+	for i, c := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = r.run(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+			break
+		}
+	}
+	r.last = &report
+	return report
+}
+
+func (r *Registry) run(ctx context.Context, c namedChecker) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	res := Result{
+		Name:      c.name,
+		Status:    StatusOK,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -1,32 +1,128 @@
 package middleware
 
 import (
-	"net/http"
+	"context"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
 	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
 )
 
-func AuthMiddleware(pas *security.PasetoService) gin.HandlerFunc {
+// Values AuthMiddleware stores in the gin context.
+const (
+	ContextUserID    = "user_id"
+	ContextSessionID = "session_id"
+	ContextAuthType  = "auth_type"
+	ContextScopes    = "auth_scopes"
+
+	AuthTypeToken  = "token"
+	AuthTypeAPIKey = "api_key"
+)
+
+var (
+	errMissingAuth       = apperr.Unauthorized("MISSING_CREDENTIALS", "missing authorization header")
+	errInvalidAuthHeader = apperr.Unauthorized("INVALID_AUTH_HEADER", "invalid authorization header")
+	errInvalidToken      = apperr.Unauthorized("INVALID_TOKEN", "invalid token")
+	errSessionInactive   = apperr.Unauthorized("SESSION_INACTIVE", "session expired or revoked")
+	errInvalidAPIKey     = apperr.Unauthorized("INVALID_API_KEY", "invalid api key")
+	errMissingScope      = apperr.Forbidden("INSUFFICIENT_SCOPE", "api key is missing a required scope")
+	errAPIKeyNotAllowed  = apperr.Forbidden("API_KEY_NOT_ALLOWED", "api keys cannot access this endpoint")
+)
+
+// APIKeyAuthenticator resolves a raw API key to its stored record.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, raw string) (*apikey.APIKey, error)
+}
+
+// SessionChecker verifies that the login session behind a token is still active.
+type SessionChecker interface {
+	CheckSession(ctx context.Context, sessionID string, userID int64) error
+}
+
+// AuthMiddleware accepts either a PASETO bearer token or an API key, sent as
+// "X-API-Key: mms_..." or "Authorization: Bearer mms_...".
+// Tokens grant full access; API keys are limited to their scopes, see RequireScope.
+// Tokens must reference a session that has not been revoked.
+func AuthMiddleware(pas *security.PasetoService, keys APIKeyAuthenticator, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if raw := c.GetHeader("X-API-Key"); raw != "" {
+			authenticateAPIKey(c, keys, raw)
+			return
+		}
+
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			abortWithError(c, errMissingAuth)
 			return
 		}
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header"})
+			abortWithError(c, errInvalidAuthHeader)
 			return
 		}
 		token := parts[1]
-		userID, err := pas.VerifyToken(token)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		if apikey.IsAPIKey(token) {
+			authenticateAPIKey(c, keys, token)
+			return
+		}
+		claims, err := pas.VerifyToken(token)
+		if err != nil || claims.SessionID == "" {
+			abortWithError(c, errInvalidToken)
+			return
+		}
+		if err := sessions.CheckSession(c.Request.Context(), claims.SessionID, claims.UserID); err != nil {
+			abortWithError(c, errSessionInactive)
+			return
+		}
+		c.Set(ContextUserID, claims.UserID)
+		withUserID(c, claims.UserID)
+		c.Set(ContextSessionID, claims.SessionID)
+		c.Set(ContextAuthType, AuthTypeToken)
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, keys APIKeyAuthenticator, raw string) {
+	k, err := keys.AuthenticateAPIKey(c.Request.Context(), raw)
+	if err != nil {
+		abortWithError(c, errInvalidAPIKey)
+		return
+	}
+	c.Set(ContextUserID, k.UserID)
+	withUserID(c, k.UserID)
+	c.Set(ContextAuthType, AuthTypeAPIKey)
+	c.Set(ContextScopes, k.Scopes)
+	c.Next()
+}
+
+// RequireScope rejects API keys that were not granted scope.
+// Requests authenticated with a PASETO token always pass.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ContextAuthType) != AuthTypeAPIKey {
+			c.Next()
+			return
+		}
+		for _, s := range c.GetStringSlice(ContextScopes) {
+			if s == scope {
+				c.Next()
+				return
+			}
+		}
+		abortWithError(c, errMissingScope.WithMessage("api key is missing scope "+scope))
+	}
+}
+
+// DenyAPIKeys restricts a route to PASETO tokens, e.g. for credential and
+// key management that a script should never be able to do.
+func DenyAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString(ContextAuthType) == AuthTypeAPIKey {
+			abortWithError(c, errAPIKeyNotAllowed)
 			return
 		}
-		c.Set("user_id", userID)
 		c.Next()
 	}
 }
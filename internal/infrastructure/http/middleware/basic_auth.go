@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+var errBasicAuth = apperr.Unauthorized("INVALID_CREDENTIALS", "invalid credentials")
+
+// BasicAuth protects internal endpoints such as /metrics with a single
+// username and password.
+func BasicAuth(username, password, realm string) gin.HandlerFunc {
+	wantUser := sha256.Sum256([]byte(username))
+	wantPass := sha256.Sum256([]byte(password))
+	return func(c *gin.Context) {
+		user, pass, ok := c.Request.BasicAuth()
+		gotUser := sha256.Sum256([]byte(user))
+		gotPass := sha256.Sum256([]byte(pass))
+		// Compare both digests every time so timing does not reveal which part was wrong.
+		userOK := subtle.ConstantTimeCompare(gotUser[:], wantUser[:])
+		passOK := subtle.ConstantTimeCompare(gotPass[:], wantPass[:])
+		if !ok || userOK&passOK != 1 {
+			c.Header("WWW-Authenticate", `Basic realm="`+realm+`"`)
+			abortWithError(c, errBasicAuth)
+			return
+		}
+		c.Next()
+	}
+}
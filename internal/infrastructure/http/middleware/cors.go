@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/pkg/requestid"
+)
+
+// CORS answers preflight requests and adds CORS headers for allowed
+// origins. Update swaps the policy atomically, so it can follow config
+// reloads while requests are in flight.
+type CORS struct {
+	policy atomic.Pointer[corsPolicy]
+}
+
+type corsPolicy struct {
+	anyOrigin   bool
+	origins     []string
+	methods     string
+	headers     string
+	credentials bool
+	maxAge      string
+}
+
+func NewCORS(cfg config.CORSConfig) *CORS {
+	m := &CORS{}
+	m.Update(cfg)
+	return m
+}
+
+// Update replaces the policy.
+func (m *CORS) Update(cfg config.CORSConfig) {
+	p := &corsPolicy{
+		anyOrigin:   slices.Contains(cfg.AllowedOrigins, "*"),
+		origins:     slices.Clone(cfg.AllowedOrigins),
+		methods:     strings.Join(cfg.AllowedMethods, ", "),
+		headers:     strings.Join(cfg.AllowedHeaders, ", "),
+		credentials: cfg.AllowCredentials,
+	}
+	if cfg.MaxAgeSeconds > 0 {
+		p.maxAge = strconv.Itoa(cfg.MaxAgeSeconds)
+	}
+	m.policy.Store(p)
+}
+
+func (m *CORS) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		p := m.policy.Load()
+		if origin == "" || !(p.anyOrigin || slices.Contains(p.origins, origin)) {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		// Credentials are only for origins that are listed by name; an
+		// origin matched by "*" could be any site.
+		if slices.Contains(p.origins, origin) {
+			h.Set("Access-Control-Allow-Origin", origin)
+			if p.credentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+		} else {
+			h.Set("Access-Control-Allow-Origin", "*")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", p.methods)
+			h.Set("Access-Control-Allow-Headers", p.headers)
+			if p.maxAge != "" {
+				h.Set("Access-Control-Max-Age", p.maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Expose-Headers", requestid.Header+", ETag, "+ReplayedHeader)
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// ErrorHandler renders the last error attached with c.Error as JSON.
+// Handlers and middleware only call c.Error (and c.Abort in middleware);
+// this is the one place that decides the response shape.
+//
+// The default body is the response.Error envelope. With problemDetails set,
+// or when the client accepts application/problem+json, an RFC 7807 problem
+// document is rendered instead.
+func ErrorHandler(problemDetails bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := apperr.From(c.Errors.Last().Err)
+
+		if err.Status >= 500 {
+			zerolog.Ctx(c.Request.Context()).Error().
+				Err(err.Err).
+				Str("code", err.Code).
+				Str("method", c.Request.Method).
+				Str("path", c.Request.URL.Path).
+				Msg("request failed")
+		}
+
+		if problemDetails || response.WantsProblem(c) {
+			response.Problem(c, err)
+			return
+		}
+		response.Error(c, err)
+	}
+}
+
+// abortWithError attaches err for ErrorHandler and stops the chain.
+func abortWithError(c *gin.Context, err *apperr.AppError) {
+	_ = c.Error(err)
+	c.Abort()
+}
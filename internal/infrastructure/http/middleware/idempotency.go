@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+const (
+	// IdempotencyKeyHeader names the client's key for a request it may retry.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a response that was stored for an earlier request.
+	ReplayedHeader = "Idempotent-Replayed"
+	// DefaultIdempotencyMaxBody caps request bodies when no limit is given.
+	DefaultIdempotencyMaxBody = 1 << 20
+)
+
+var (
+	errUnreadableBody = apperr.BadRequest("INVALID_BODY", "request body could not be read")
+	errBodyTooLarge   = apperr.New("BODY_TOO_LARGE", http.StatusRequestEntityTooLarge, "request body is too large")
+)
+
+// replayedHeaders are stored and replayed along with the status and body.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// IdempotencyStore remembers the responses of requests sent with an
+// Idempotency-Key.
+type IdempotencyStore interface {
+	Begin(ctx context.Context, userID int64, key, hash string) (*idempotency.Record, error)
+	Complete(ctx context.Context, userID int64, key string, status int, header map[string]string, body []byte) error
+	Release(ctx context.Context, userID int64, key string) error
+}
+
+// Idempotency lets clients retry POST, PUT, PATCH and DELETE requests
+// safely. The first request with a given Idempotency-Key runs, and if it
+// succeeds its response is stored; retries with the same method, path and
+// body get that response back with Idempotent-Replayed: true. Reusing the
+// key for a different request is refused with 422, and a retry that arrives
+// while the first request is still running with 409. A request that fails
+// releases its key, so it can be retried; so does one that panics or whose
+// response cannot be stored. The
+// body is read into memory to be hashed, so bodies over maxBody bytes are
+// refused with 413. Requests without the header are not affected. It must
+// run after AuthMiddleware, as keys belong to a user.
+func Idempotency(store IdempotencyStore, maxBody int64) gin.HandlerFunc {
+	if maxBody <= 0 {
+		maxBody = DefaultIdempotencyMaxBody
+	}
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" || !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				abortWithError(c, errBodyTooLarge)
+				return
+			}
+			abortWithError(c, errUnreadableBody.Wrap(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := c.GetInt64(ContextUserID)
+		hash := idempotency.HashRequest(c.Request.Method, c.Request.URL.Path, body)
+		stored, err := store.Begin(c.Request.Context(), userID, key, hash)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			replay(c, stored)
+			return
+		}
+
+		// The response is on its way either way, so a client that hangs up
+		// must not keep the key from being completed or released.
+		ctx := context.WithoutCancel(c.Request.Context())
+		defer func() {
+			if p := recover(); p != nil {
+				_ = store.Release(ctx, userID, key)
+				panic(p)
+			}
+		}()
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := c.Writer.Status()
+		if len(c.Errors) > 0 || status >= http.StatusBadRequest {
+			_ = store.Release(ctx, userID, key)
+			return
+		}
+		header := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		if err := store.Complete(ctx, userID, key, status, header, recorder.body.Bytes()); err != nil {
+			// A key left in progress would answer every retry with 409.
+			_ = store.Release(ctx, userID, key)
+		}
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func replay(c *gin.Context, r *idempotency.Record) {
+	for name, value := range r.Header {
+		c.Header(name, value)
+	}
+	c.Header(ReplayedHeader, "true")
+	c.Data(r.Status, r.Header["Content-Type"], r.Body)
+	c.Abort()
+}
+
+// bodyRecorder keeps a copy of the response body.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
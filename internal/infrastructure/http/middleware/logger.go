@@ -4,10 +4,12 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/rs/zerolog"
 )
 
 // RequestLogger is a Gin middleware that logs HTTP requests using Zerolog.
+// It logs through the request logger set by RequestID, so the line carries
+// the request ID.
 func RequestLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -22,7 +24,7 @@ func RequestLogger() gin.HandlerFunc {
 		path := c.Request.URL.Path
 		clientIP := c.ClientIP()
 
-		logger.L.Info().
+		zerolog.Ctx(c.Request.Context()).Info().
 			Str("method", method).
 			Str("path", path).
 			Int("status", status).
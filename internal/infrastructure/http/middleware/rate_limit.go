@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/config"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// idleClientTTL is how long a client's bucket is kept after its last
+// request; a full bucket carries no state worth keeping.
+const idleClientTTL = 10 * time.Minute
+
+// maxRateClients bounds the tracked clients. When it is reached a client is
+// evicted at random; at worst that client gets a fresh bucket.
+const maxRateClients = 100000
+
+var errRateLimited = apperr.New(apperr.CodeTooManyRequests, http.StatusTooManyRequests, "too many requests")
+
+// RateLimiter limits each client IP address with a token bucket. The
+// address is gin's ClientIP, so X-Forwarded-For only counts when the
+// request came through one of server.trusted_proxies. Update
+// applies new limits to every client atomically, so it can follow config
+// reloads.
+type RateLimiter struct {
+	settings atomic.Pointer[config.RateLimitConfig]
+
+	mu        sync.Mutex
+	clients   map[string]*rateClient
+	lastPrune time.Time
+}
+
+type rateClient struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func NewRateLimiter(cfg config.RateLimitConfig) *RateLimiter {
+	l := &RateLimiter{clients: make(map[string]*rateClient)}
+	l.Update(cfg)
+	return l
+}
+
+// Update replaces the limits of new and existing clients.
+func (l *RateLimiter) Update(cfg config.RateLimitConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.settings.Store(&cfg)
+	for _, c := range l.clients {
+		c.limiter.SetLimit(rate.Limit(cfg.RequestsPerSecond))
+		c.limiter.SetBurst(cfg.Burst)
+	}
+}
+
+func (l *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !l.settings.Load().Enabled {
+			c.Next()
+			return
+		}
+		res := l.client(c.ClientIP()).Reserve()
+		if delay := res.Delay(); delay > 0 {
+			res.Cancel()
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			abortWithError(c, errRateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+func (l *RateLimiter) client(key string) *rate.Limiter {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastPrune) > idleClientTTL {
+		for k, c := range l.clients {
+			if now.Sub(c.lastSeen) > idleClientTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		if len(l.clients) >= maxRateClients {
+			for k := range l.clients {
+				delete(l.clients, k)
+				break
+			}
+		}
+		cfg := l.settings.Load()
+		c = &rateClient{limiter: rate.NewLimiter(rate.Limit(cfg.RequestsPerSecond), cfg.Burst)}
+		l.clients[key] = c
+	}
+	c.lastSeen = now
+	return c.limiter
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/pkg/actor"
+	"github.com/luthfiarsyad/mms/pkg/clientip"
+	"github.com/luthfiarsyad/mms/pkg/requestid"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ContextRequestID is the gin context key of the request ID.
+const ContextRequestID = "request_id"
+
+// RequestID accepts a valid X-Request-ID from the client or generates one,
+// echoes it in the response and stores it in the request context together
+// with the client IP and a child logger carrying request_id, route and, when the request is
+// traced, trace_id and span_id. Code that receives the context logs through
+// zerolog.Ctx(ctx); AuthMiddleware adds user_id.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Set(ContextRequestID, id)
+		c.Header(requestid.Header, id)
+
+		lc := logger.L.With().
+			Str("request_id", id).
+			Str("route", c.FullPath())
+		// Set by Tracing, which runs first.
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+			lc = lc.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+		}
+		l := lc.Logger()
+		ctx := requestid.NewContext(c.Request.Context(), id)
+		ctx = clientip.NewContext(ctx, c.ClientIP())
+		c.Request = c.Request.WithContext(l.WithContext(ctx))
+		c.Next()
+	}
+}
+
+// withUserID stores the authenticated user in the request context and adds
+// it to the request logger. Without RequestID there is no request logger,
+// and zerolog.Ctx would return the shared default logger, which must not be
+// modified.
+func withUserID(c *gin.Context, userID int64) {
+	c.Request = c.Request.WithContext(actor.NewContext(c.Request.Context(), userID))
+	if _, ok := c.Get(ContextRequestID); !ok {
+		return
+	}
+	zerolog.Ctx(c.Request.Context()).UpdateContext(func(zc zerolog.Context) zerolog.Context {
+		return zc.Int64("user_id", userID)
+	})
+}
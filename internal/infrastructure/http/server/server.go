@@ -0,0 +1,117 @@
+// Package server runs the HTTP server and shuts it down in order: readiness
+// fails, in-flight requests drain, background workers stop and resources
+// such as the database close last.
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+)
+
+const (
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 60 * time.Second
+	DefaultShutdownTimeout = 20 * time.Second
+	DefaultAddress         = ":8080"
+)
+
+type Server struct {
+	srv             *http.Server
+	lc              *lifecycle.Manager
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+}
+
+func New(cfg config.ServerConfig, handler http.Handler, lc *lifecycle.Manager) *Server {
+	addr := cfg.Address
+	if addr == "" {
+		addr = DefaultAddress
+	}
+	return &Server{
+		srv: &http.Server{
+			Addr:         addr,
+			Handler:      handler,
+			ReadTimeout:  seconds(cfg.ReadTimeoutSeconds, DefaultReadTimeout),
+			WriteTimeout: seconds(cfg.WriteTimeoutSeconds, DefaultWriteTimeout),
+			IdleTimeout:  seconds(cfg.IdleTimeoutSeconds, DefaultIdleTimeout),
+		},
+		lc:              lc,
+		shutdownDelay:   seconds(cfg.ShutdownDelaySeconds, 0),
+		shutdownTimeout: seconds(cfg.ShutdownTimeoutSeconds, DefaultShutdownTimeout),
+	}
+}
+
+// Run serves until ctx is cancelled, typically by SIGINT or SIGTERM, or the
+// listener fails, and then shuts down. It returns once every closer of the
+// lifecycle manager has run.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	s.srv.BaseContext = func(net.Listener) context.Context { return s.lc.Context() }
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.L.Info().Str("address", ln.Addr().String()).Msg("Server: listening")
+		serveErr <- s.srv.Serve(ln)
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.L.Info().Msg("Server: shutdown signal received")
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			runErr = err
+		}
+	}
+	return errors.Join(runErr, s.shutdown())
+}
+
+func (s *Server) shutdown() error {
+	s.lc.BeginShutdown()
+	if s.shutdownDelay > 0 {
+		time.Sleep(s.shutdownDelay)
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := s.srv.Shutdown(drainCtx); err != nil {
+		logger.L.Warn().Err(err).Msg("Server: requests did not drain in time, closing connections")
+		errs = append(errs, err, s.srv.Close())
+	} else {
+		logger.L.Info().Msg("Server: in-flight requests drained")
+	}
+
+	// Workers get a budget of their own: requests that used up the drain
+	// deadline must not keep them from stopping and closing resources.
+	stopCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+	if err := s.lc.Stop(stopCtx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func seconds(n int, def time.Duration) time.Duration {
+	if n <= 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,113 @@
+// Package lifecycle coordinates shutdown of the process. Background workers
+// run under a context owned by the Manager, and resources such as the
+// database register closers that run once every worker has returned.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+)
+
+type closer struct {
+	name string
+	fn   func() error
+}
+
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	shuttingDown atomic.Bool
+	workers      sync.WaitGroup
+
+	mu      sync.Mutex
+	closers []closer
+	stopped bool
+}
+
+// New returns a Manager whose worker context is derived from parent.
+func New(parent context.Context) *Manager {
+	ctx, cancel := context.WithCancel(parent)
+	return &Manager{ctx: ctx, cancel: cancel}
+}
+
+// Context is cancelled when workers are asked to stop.
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Go runs fn in a goroutine. fn must return soon after its context is
+// cancelled; Stop waits for it.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		logger.L.Debug().Str("worker", name).Msg("Lifecycle: worker started")
+		fn(m.ctx)
+		logger.L.Debug().Str("worker", name).Msg("Lifecycle: worker stopped")
+	}()
+}
+
+// OnClose registers fn to run after all workers have stopped. Closers run in
+// reverse registration order, so whatever is opened first is closed last.
+func (m *Manager) OnClose(name string, fn func() error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closers = append(m.closers, closer{name: name, fn: fn})
+}
+
+// BeginShutdown marks the process as shutting down so readiness checks start
+// failing while in-flight requests are still being served.
+func (m *Manager) BeginShutdown() {
+	if m.shuttingDown.CompareAndSwap(false, true) {
+		logger.L.Info().Msg("Lifecycle: shutdown started")
+	}
+}
+
+func (m *Manager) ShuttingDown() bool {
+	return m.shuttingDown.Load()
+}
+
+// Stop cancels the worker context, waits for workers until ctx expires and
+// then runs the closers. Closers run even if workers did not finish in time.
+// Stop only runs once; later calls return nil.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	if m.stopped {
+		m.mu.Unlock()
+		return nil
+	}
+	m.stopped = true
+	closers := m.closers
+	m.mu.Unlock()
+
+	m.BeginShutdown()
+	m.cancel()
+
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		m.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("workers did not stop in time: %w", ctx.Err()))
+	}
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		c := closers[i]
+		if err := c.fn(); err != nil {
+			errs = append(errs, fmt.Errorf("close %s: %w", c.name, err))
+			continue
+		}
+		logger.L.Debug().Str("resource", c.name).Msg("Lifecycle: closed")
+	}
+	return errors.Join(errs...)
+}
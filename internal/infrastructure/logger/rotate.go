@@ -0,0 +1,207 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is appended to rotated file names; it sorts
+// chronologically as a string.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// maxBackupNameAttempts bounds the search for an unused backup name.
+const maxBackupNameAttempts = 1000
+
+// RotatingFile is an io.Writer that appends to a file and rotates it when it
+// grows past maxSize bytes or has been open longer than interval. Rotated
+// files are renamed to <name>-<timestamp><ext> next to the original, and
+// only the newest maxBackups are kept. When the file cannot be reopened
+// after a rotation, the next Write tries again. It is safe for concurrent
+// use.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	interval   time.Duration
+	maxBackups int
+	now        func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	closed   bool
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFile opens (or creates) path for appending. Zero maxSize or
+// interval disables that rotation rule; zero maxBackups keeps every file.
+func NewRotatingFile(path string, maxSize int64, interval time.Duration, maxBackups int) (*RotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log file path is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	f := &RotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		interval:   interval,
+		maxBackups: maxBackups,
+		now:        time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, os.ErrClosed
+	}
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one regardless of size
+// and age.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(next int64) bool {
+	// A single event larger than maxSize still goes into an empty file.
+	if f.maxSize > 0 && f.size > 0 && f.size+next > f.maxSize {
+		return true
+	}
+	return f.interval > 0 && f.now().Sub(f.openedAt) >= f.interval
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// rotate must be called with f.mu held. If it fails after closing the
+// file, f.file stays nil and the next Write reopens it.
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return fmt.Errorf("close log file: %w", err)
+		}
+		f.file = nil
+	}
+	name, err := f.backupName()
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(f.path, name); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("rename log file: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.prune()
+	return nil
+}
+
+// backupName returns an unused name for the file being rotated out. Names
+// stay unique and ordered even for rotations within one clock tick.
+func (f *RotatingFile) backupName() (string, error) {
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext) + "-"
+	t := f.now()
+	for range maxBackupNameAttempts {
+		name := base + t.Format(backupTimeFormat) + ext
+		_, err := os.Stat(name)
+		if os.IsNotExist(err) {
+			return name, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("check backup log file: %w", err)
+		}
+		t = t.Add(time.Nanosecond)
+	}
+	return "", fmt.Errorf("no unused backup log file name after %d attempts", maxBackupNameAttempts)
+}
+
+// prune removes the oldest backups beyond maxBackups. Failures are ignored:
+// a leftover file is better than losing the log line being written.
+func (f *RotatingFile) prune() {
+	if f.maxBackups <= 0 {
+		return
+	}
+	backups := f.backups()
+	if len(backups) <= f.maxBackups {
+		return
+	}
+	for _, name := range backups[:len(backups)-f.maxBackups] {
+		_ = os.Remove(name)
+	}
+}
+
+// backups lists rotated files, oldest first. Other files that happen to
+// share the prefix, such as mms-access.log next to mms.log, are left out.
+func (f *RotatingFile) backups() []string {
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext) + "-"
+	matches, _ := filepath.Glob(base + "*" + ext)
+	var backups []string
+	for _, name := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, base), ext)
+		if len(stamp) != len(backupTimeFormat) {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
@@ -1,38 +1,121 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"time"
 
+	"github.com/luthfiarsyad/mms/config"
 	"github.com/rs/zerolog"
 )
 
+// Log formats and outputs accepted in config.LogConfig.
+const (
+	FormatConsole = "console"
+	FormatJSON    = "json"
+	OutputStdout  = "stdout"
+	OutputFile    = "file"
+)
+
 // L is the global logger instance accessible across packages after Init() call.
 var L zerolog.Logger
 
-// Init initializes a zerolog.Logger with the given log level.
-// You typically call this once in main.go after config.Load().
-func Init(level string) {
+// file is the log file opened by Init, if any.
+var file io.Closer
+
+// Init initializes L from cfg: format, destination, redaction and debug
+// sampling. You typically call this once in main.go after config.Load().
+//
+// The level is applied globally rather than on L, so SetLevel also affects
+// loggers already derived from L, such as request loggers.
+func Init(cfg config.LogConfig) error {
 	// default: info
-	lvl, err := zerolog.ParseLevel(level)
-	if err != nil {
+	lvl, err := zerolog.ParseLevel(cfg.Level)
+	if err != nil || cfg.Level == "" {
 		lvl = zerolog.InfoLevel
 	}
 
-	// configure global time format and pretty console output for dev mode
-	output := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
+	var out io.Writer
+	switch cfg.Output {
+	case "", OutputStdout:
+		out = os.Stdout
+	case OutputFile:
+		fc := cfg.File
+		f, err := NewRotatingFile(fc.Path, int64(fc.MaxSizeMB)<<20,
+			time.Duration(fc.RotateIntervalHours)*time.Hour, fc.MaxBackups)
+		if err != nil {
+			return err
+		}
+		out, file = f, f
+	default:
+		return fmt.Errorf("unknown log output %q", cfg.Output)
+	}
+
+	switch cfg.Format {
+	case "", FormatConsole:
+		// pretty console output for dev mode; colors only make sense on a terminal
+		out = zerolog.ConsoleWriter{
+			Out:        out,
+			TimeFormat: time.RFC3339,
+			NoColor:    cfg.Output == OutputFile,
+		}
+	case FormatJSON:
+		zerolog.TimeFieldFormat = time.RFC3339Nano
+	default:
+		return fmt.Errorf("unknown log format %q", cfg.Format)
 	}
 
-	L = zerolog.New(output).
-		Level(lvl).
+	// Redaction sees the JSON event before the console writer reformats it.
+	if cfg.Redact {
+		out = redactWriter{out: out}
+	}
+
+	l := zerolog.New(out).
 		With().
 		Timestamp().
 		Caller().
 		Logger()
 
+	if s := cfg.Sampling; s.DebugEvery > 1 {
+		l = l.Sample(zerolog.LevelSampler{
+			DebugSampler: &zerolog.BurstSampler{
+				Burst:       s.DebugBurst,
+				Period:      time.Second,
+				NextSampler: &zerolog.BasicSampler{N: s.DebugEvery},
+			},
+		})
+	}
+
+	L = l
 	zerolog.SetGlobalLevel(lvl)
+
+	// zerolog.Ctx falls back to L for contexts without a request logger.
+	zerolog.DefaultContextLogger = &L
+	return nil
+}
+
+// Level returns the current global log level.
+func Level() zerolog.Level {
+	return zerolog.GlobalLevel()
+}
+
+// SetLevel changes the global log level at runtime.
+func SetLevel(level string) error {
+	lvl, err := zerolog.ParseLevel(level)
+	if err != nil || level == "" {
+		return fmt.Errorf("unknown log level %q", level)
+	}
+	zerolog.SetGlobalLevel(lvl)
+	return nil
+}
+
+// Close closes the log file, if Init opened one.
+func Close() error {
+	if file == nil {
+		return nil
+	}
+	return file.Close()
 }
 
 // Get returns the initialized logger instance (in case you prefer function access).
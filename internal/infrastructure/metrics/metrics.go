@@ -0,0 +1,147 @@
+// Package metrics owns the Prometheus registry served on /metrics: HTTP
+// traffic by route template, database pool statistics and business
+// counters recorded by the usecases.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "mms"
+
+// Registry holds every collector of the process. A dedicated registry keeps
+// tests and multiple routers from colliding on the global default one.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result.",
+	}, []string{"result"})
+
+	registrations = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registrations_total",
+		Help:      "Successful user registrations.",
+	})
+
+	transactionsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transactions_created_total",
+		Help:      "Transactions created by type.",
+	}, []string{"type"})
+
+	db = &dbStats{}
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		logins,
+		registrations,
+		transactionsCreated,
+		db,
+	)
+	// Export the labels with zero values so rates work from the first scrape.
+	logins.WithLabelValues("success")
+	logins.WithLabelValues("failure")
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// Middleware records every request under its route template, e.g.
+// /api/v1/transactions/:id, so raw ids do not explode label cardinality.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		httpRequests.WithLabelValues(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		httpDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// ObserveDB exports the sql.DBStats of conn. Calling it again replaces the
+// observed pool.
+func ObserveDB(conn *sql.DB) {
+	if conn == nil {
+		return
+	}
+	db.set(collectors.NewDBStatsCollector(conn, namespace))
+}
+
+// ObserveLogin counts a login attempt.
+func ObserveLogin(success bool) {
+	if success {
+		logins.WithLabelValues("success").Inc()
+		return
+	}
+	logins.WithLabelValues("failure").Inc()
+}
+
+// ObserveRegistration counts a successful registration.
+func ObserveRegistration() {
+	registrations.Inc()
+}
+
+// ObserveTransactionCreated counts a created transaction of txType.
+func ObserveTransactionCreated(txType string) {
+	transactionsCreated.WithLabelValues(txType).Inc()
+}
+
+// dbStats forwards to the collector of the current pool. It is unchecked
+// (Describe sends nothing) because the pool is only known after Connect.
+type dbStats struct {
+	mu sync.RWMutex
+	c  prometheus.Collector
+}
+
+func (d *dbStats) set(c prometheus.Collector) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.c = c
+}
+
+func (d *dbStats) Describe(chan<- *prometheus.Desc) {}
+
+func (d *dbStats) Collect(ch chan<- prometheus.Metric) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.c != nil {
+		d.c.Collect(ch)
+	}
+}
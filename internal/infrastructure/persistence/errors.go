@@ -0,0 +1,14 @@
+// Package persistence holds what the storage backends share: the errors
+// constraint violations are translated to, the traced statement wrapper and
+// the Transactor.
+package persistence
+
+import apperr "github.com/luthfiarsyad/mms/pkg/errors"
+
+// Every backend maps its constraint violations to these, so callers see the
+// same errors whichever database is configured.
+var (
+	ErrDuplicateEntry   = apperr.Conflict("DUPLICATE_ENTRY", "resource already exists")
+	ErrStillReferenced  = apperr.Conflict("RESOURCE_IN_USE", "resource is still referenced")
+	ErrReferenceMissing = apperr.NotFound("REFERENCE_NOT_FOUND", "referenced resource does not exist")
+)
@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"sort"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type APIKeyRepo struct {
+	db *DB
+}
+
+func NewAPIKeyRepo(db *DB) *APIKeyRepo {
+	return &APIKeyRepo{db: db}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, taken := r.db.prefixes[k.Prefix]; taken {
+		return persistence.ErrDuplicateEntry
+	}
+	if _, ok := r.db.users[k.UserID]; !ok {
+		return persistence.ErrReferenceMissing
+	}
+	r.db.nextAPIKeyID++
+	k.ID = r.db.nextAPIKeyID
+	s := copyAPIKey(k)
+	s.ExpiresAt = timestampPtr(k.ExpiresAt)
+	s.LastUsedAt, s.RevokedAt = nil, nil
+	s.CreatedAt = timestamp(k.CreatedAt)
+	r.db.apiKeys[k.ID] = s
+	r.db.prefixes[k.Prefix] = k.ID
+	r.db.onRollback(ctx, func() {
+		delete(r.db.apiKeys, s.ID)
+		delete(r.db.prefixes, s.Prefix)
+	})
+	return nil
+}
+
+func (r *APIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	id, ok := r.db.prefixes[prefix]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyAPIKey(r.db.apiKeys[id]), nil
+}
+
+func (r *APIKeyRepo) FindByUserID(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var keys []*domain.APIKey
+	for _, k := range r.db.apiKeys {
+		if k.UserID == userID {
+			keys = append(keys, copyAPIKey(k))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id, userID int64, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	k, ok := r.db.apiKeys[id]
+	if !ok || k.UserID != userID || k.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	k.RevokedAt = timestampPtr(&at)
+	r.db.onRollback(ctx, func() { k.RevokedAt = nil })
+	return nil
+}
+
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if k, ok := r.db.apiKeys[id]; ok {
+		previous := k.LastUsedAt
+		k.LastUsedAt = timestampPtr(&at)
+		r.db.onRollback(ctx, func() { k.LastUsedAt = previous })
+	}
+	return nil
+}
+
+// copyAPIKey copies k deeply enough that neither side sees later changes of
+// the other. Like a scanned row, a key without scopes has nil Scopes.
+func copyAPIKey(k *domain.APIKey) *domain.APIKey {
+	c := *k
+	c.Scopes = slices.Clone(k.Scopes)
+	if len(c.Scopes) == 0 {
+		c.Scopes = nil
+	}
+	c.ExpiresAt = copyTime(k.ExpiresAt)
+	c.LastUsedAt = copyTime(k.LastUsedAt)
+	c.RevokedAt = copyTime(k.RevokedAt)
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/audit"
+)
+
+type AuditRepo struct {
+	db *DB
+}
+
+func NewAuditRepo(db *DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+// Head needs no lock of its own: the transactions of a Transactor already
+// run one at a time.
+func (r *AuditRepo) Head(ctx context.Context) (string, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	return r.db.auditHead, nil
+}
+
+func (r *AuditRepo) Append(ctx context.Context, a *domain.Record) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.nextAuditID++
+	a.ID = r.db.nextAuditID
+	s := copyAuditRecord(a)
+	s.CreatedAt = timestamp(a.CreatedAt)
+	previous := r.db.auditHead
+	r.db.auditLog = append(r.db.auditLog, s)
+	r.db.auditHead = a.Hash
+	r.db.onRollback(ctx, func() {
+		r.db.auditLog = slices.DeleteFunc(r.db.auditLog, func(stored *domain.Record) bool { return stored == s })
+		r.db.auditHead = previous
+	})
+	return nil
+}
+
+func (r *AuditRepo) Find(ctx context.Context, f domain.Filter) ([]*domain.Record, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var (
+		records []*domain.Record
+		skipped int
+	)
+	for i := len(r.db.auditLog) - 1; i >= 0; i-- {
+		a := r.db.auditLog[i]
+		switch {
+		case !auditMatches(f, a):
+			continue
+		case f.Limit > 0 && skipped < f.Offset:
+			skipped++
+			continue
+		case f.Limit > 0 && len(records) == f.Limit:
+			return records, nil
+		}
+		records = append(records, copyAuditRecord(a))
+	}
+	return records, nil
+}
+
+func (r *AuditRepo) Count(ctx context.Context, f domain.Filter) (int, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	n := 0
+	for _, a := range r.db.auditLog {
+		if auditMatches(f, a) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func auditMatches(f domain.Filter, a *domain.Record) bool {
+	switch {
+	case f.ActorID != 0 && a.ActorID != f.ActorID,
+		f.EntityType != "" && a.EntityType != f.EntityType,
+		f.EntityID != 0 && a.EntityID != f.EntityID,
+		!f.From.IsZero() && a.CreatedAt.Before(f.From),
+		!f.To.IsZero() && !a.CreatedAt.Before(f.To):
+		return false
+	}
+	return true
+}
+
+func (r *AuditRepo) Chain(ctx context.Context, afterID int64, limit int) ([]*domain.Record, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var records []*domain.Record
+	for _, a := range r.db.auditLog {
+		if len(records) == limit {
+			break
+		}
+		if a.ID > afterID {
+			records = append(records, copyAuditRecord(a))
+		}
+	}
+	return records, nil
+}
+
+func copyAuditRecord(a *domain.Record) *domain.Record {
+	c := *a
+	c.Before = slices.Clone(a.Before)
+	c.After = slices.Clone(a.After)
+	return &c
+}
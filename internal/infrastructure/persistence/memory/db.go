@@ -0,0 +1,76 @@
+// Package memory keeps the repositories in process memory, for unit tests
+// and demos that should not need a database server. It follows the MySQL
+// backend: the same conflicts, foreign keys, ordering, not-found errors and
+// column precision. Everything is lost when the process exits.
+package memory
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+)
+
+// DB holds the tables shared by the repositories, so that references between
+// them can be checked. It is safe for concurrent use.
+type DB struct {
+	mu sync.RWMutex
+	// txMu runs the transactions of a Transactor one at a time.
+	txMu sync.Mutex
+
+	users        map[int64]*user.User
+	emails       map[string]int64 // lower-cased email to user ID
+	transactions map[int64]*transaction.Transaction
+	apiKeys      map[int64]*apikey.APIKey
+	prefixes     map[string]int64
+	sessions     map[string]*session.Session
+	auditLog     []*audit.Record
+	auditHead    string
+	idempotency  map[idempotencyKey]*idempotency.Record
+
+	nextUserID, nextTxID, nextAPIKeyID, nextAuditID int64
+}
+
+// New returns an empty database.
+func New() *DB {
+	return &DB{
+		users:        make(map[int64]*user.User),
+		emails:       make(map[string]int64),
+		transactions: make(map[int64]*transaction.Transaction),
+		apiKeys:      make(map[int64]*apikey.APIKey),
+		prefixes:     make(map[string]int64),
+		sessions:     make(map[string]*session.Session),
+		idempotency:  make(map[idempotencyKey]*idempotency.Record),
+	}
+}
+
+// emailKey compares emails case-insensitively, like MySQL's default
+// collation.
+func emailKey(email string) string {
+	return strings.ToLower(email)
+}
+
+// timestamp stores t the way a MySQL TIMESTAMP column does: whole seconds.
+func timestamp(t time.Time) time.Time {
+	return t.Round(time.Second)
+}
+
+func timestampPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	ts := timestamp(*t)
+	return &ts
+}
+
+// decimal stores v the way a DECIMAL(10,2) column does.
+func decimal(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+// idempotencyKey is the primary key of the idempotency records.
+type idempotencyKey struct {
+	userID int64
+	key    string
+}
+
+type IdempotencyRepo struct {
+	db *DB
+}
+
+func NewIdempotencyRepo(db *DB) *IdempotencyRepo {
+	return &IdempotencyRepo{db: db}
+}
+
+func (r *IdempotencyRepo) Create(ctx context.Context, k *domain.Record) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	id := idempotencyKey{k.UserID, k.Key}
+	if _, taken := r.db.idempotency[id]; taken {
+		return domain.ErrKeyExists
+	}
+	if _, ok := r.db.users[k.UserID]; !ok {
+		return persistence.ErrReferenceMissing
+	}
+	stored := copyIdempotencyRecord(k)
+	stored.Status, stored.Header, stored.Body = 0, nil, nil
+	stored.CreatedAt = timestamp(k.CreatedAt)
+	stored.ExpiresAt = timestamp(k.ExpiresAt)
+	r.db.idempotency[id] = stored
+	r.db.onRollback(ctx, func() { delete(r.db.idempotency, id) })
+	return nil
+}
+
+func (r *IdempotencyRepo) Find(ctx context.Context, userID int64, key string) (*domain.Record, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	k, ok := r.db.idempotency[idempotencyKey{userID, key}]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copyIdempotencyRecord(k), nil
+}
+
+func (r *IdempotencyRepo) Complete(ctx context.Context, k *domain.Record) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	stored, ok := r.db.idempotency[idempotencyKey{k.UserID, k.Key}]
+	if !ok {
+		return nil
+	}
+	previous := *stored
+	r.db.onRollback(ctx, func() { *stored = previous })
+	stored.Status = k.Status
+	stored.Header = maps.Clone(k.Header)
+	stored.Body = slices.Clone(k.Body)
+	return nil
+}
+
+func (r *IdempotencyRepo) Delete(ctx context.Context, userID int64, key string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	id := idempotencyKey{userID, key}
+	if k, ok := r.db.idempotency[id]; ok {
+		delete(r.db.idempotency, id)
+		r.db.onRollback(ctx, func() { r.db.idempotency[id] = k })
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) DeleteAbandoned(ctx context.Context, userID int64, key string, startedBefore time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	id := idempotencyKey{userID, key}
+	if k, ok := r.db.idempotency[id]; ok && !k.Completed() && k.CreatedAt.Before(startedBefore) {
+		delete(r.db.idempotency, id)
+		r.db.onRollback(ctx, func() { r.db.idempotency[id] = k })
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var purged int64
+	for id, k := range r.db.idempotency {
+		if k.ExpiresAt.Before(cutoff) {
+			delete(r.db.idempotency, id)
+			r.db.onRollback(ctx, func() { r.db.idempotency[id] = k })
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func copyIdempotencyRecord(k *domain.Record) *domain.Record {
+	c := *k
+	c.Header = maps.Clone(k.Header)
+	c.Body = slices.Clone(k.Body)
+	return &c
+}
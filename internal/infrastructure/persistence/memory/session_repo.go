@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type SessionRepo struct {
+	db *DB
+}
+
+func NewSessionRepo(db *DB) *SessionRepo {
+	return &SessionRepo{db: db}
+}
+
+func (r *SessionRepo) Create(ctx context.Context, s *domain.Session) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, taken := r.db.sessions[s.ID]; taken {
+		return persistence.ErrDuplicateEntry
+	}
+	if _, ok := r.db.users[s.UserID]; !ok {
+		return persistence.ErrReferenceMissing
+	}
+	stored := *s
+	stored.CreatedAt = timestamp(s.CreatedAt)
+	stored.LastSeenAt = timestamp(s.LastSeenAt)
+	stored.ExpiresAt = timestamp(s.ExpiresAt)
+	stored.RevokedAt = nil
+	r.db.sessions[s.ID] = &stored
+	r.db.onRollback(ctx, func() { delete(r.db.sessions, stored.ID) })
+	return nil
+}
+
+func (r *SessionRepo) FindByID(ctx context.Context, id string) (*domain.Session, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	s, ok := r.db.sessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return copySession(s), nil
+}
+
+func (r *SessionRepo) FindActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]*domain.Session, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var sessions []*domain.Session
+	for _, s := range r.db.sessions {
+		if s.UserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			sessions = append(sessions, copySession(s))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		a, b := sessions[i], sessions[j]
+		if !a.LastSeenAt.Equal(b.LastSeenAt) {
+			return a.LastSeenAt.After(b.LastSeenAt)
+		}
+		return a.ID < b.ID
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepo) Revoke(ctx context.Context, id string, userID int64, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	s, ok := r.db.sessions[id]
+	if !ok || s.UserID != userID || s.RevokedAt != nil {
+		return sql.ErrNoRows
+	}
+	s.RevokedAt = timestampPtr(&at)
+	r.db.onRollback(ctx, func() { s.RevokedAt = nil })
+	return nil
+}
+
+func (r *SessionRepo) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if s, ok := r.db.sessions[id]; ok {
+		previous := s.LastSeenAt
+		s.LastSeenAt = timestamp(at)
+		r.db.onRollback(ctx, func() { s.LastSeenAt = previous })
+	}
+	return nil
+}
+
+func copySession(s *domain.Session) *domain.Session {
+	c := *s
+	c.RevokedAt = copyTime(s.RevokedAt)
+	return &c
+}
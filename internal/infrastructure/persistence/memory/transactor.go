@@ -0,0 +1,62 @@
+package memory
+
+import "context"
+
+type txKey struct{ db *DB }
+
+// memTx collects what undoes the writes made in a transaction, newest last.
+type memTx struct {
+	undo []func()
+}
+
+// Transactor gives the in-memory repositories transactions. Transactions run
+// one at a time and are rolled back by undoing their writes; they are not
+// isolated, so reads outside a transaction may see writes it later undoes.
+type Transactor struct {
+	db *DB
+}
+
+func NewTransactor(db *DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx runs fn in a transaction that is kept when fn returns nil and
+// undone when it fails or panics. Calls nested in fn join the outer
+// transaction.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if _, ok := ctx.Value(txKey{t.db}).(*memTx); ok {
+		return fn(ctx)
+	}
+
+	t.db.txMu.Lock()
+	defer t.db.txMu.Unlock()
+
+	tx := &memTx{}
+	defer func() {
+		if p := recover(); p != nil {
+			t.db.rollback(tx)
+			panic(p)
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{t.db}, tx)); err != nil {
+		t.db.rollback(tx)
+		return err
+	}
+	return nil
+}
+
+// onRollback registers undo to run if the transaction of ctx is rolled back.
+// It must be called with mu held; outside a transaction it does nothing.
+func (db *DB) onRollback(ctx context.Context, undo func()) {
+	if tx, ok := ctx.Value(txKey{db}).(*memTx); ok {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+func (db *DB) rollback(tx *memTx) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
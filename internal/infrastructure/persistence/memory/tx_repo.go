@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type TxRepo struct {
+	db *DB
+}
+
+func NewTxRepo(db *DB) *TxRepo {
+	return &TxRepo{db: db}
+}
+
+func (r *TxRepo) Create(ctx context.Context, t *domain.Transaction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, ok := r.db.users[t.UserID]; !ok {
+		return persistence.ErrReferenceMissing
+	}
+	r.db.nextTxID++
+	t.ID = r.db.nextTxID
+	t.Version = 1
+	r.db.transactions[t.ID] = stored(t)
+	id := t.ID
+	r.db.onRollback(ctx, func() { delete(r.db.transactions, id) })
+	return nil
+}
+
+func (r *TxRepo) CreateMany(ctx context.Context, ts []*domain.Transaction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	for _, t := range ts {
+		if _, ok := r.db.users[t.UserID]; !ok {
+			return persistence.ErrReferenceMissing
+		}
+	}
+	for _, t := range ts {
+		r.db.nextTxID++
+		t.ID = r.db.nextTxID
+		t.Version = 1
+		r.db.transactions[t.ID] = stored(t)
+		id := t.ID
+		r.db.onRollback(ctx, func() { delete(r.db.transactions, id) })
+	}
+	return nil
+}
+
+func (r *TxRepo) FindByID(ctx context.Context, id int64) (*domain.Transaction, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	t, ok := r.db.transactions[id]
+	if !ok || t.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	return copyTransaction(t), nil
+}
+
+// FindByUserID returns the user's transactions newest first; ties are broken
+// by ID so the order is stable.
+func (r *TxRepo) FindByUserID(ctx context.Context, userID int64, page domain.Page) ([]*domain.Transaction, error) {
+	transactions := r.find(userID, false)
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return paged(transactions, page), nil
+}
+
+func (r *TxRepo) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	return len(r.find(userID, false)), nil
+}
+
+func (r *TxRepo) Update(ctx context.Context, t *domain.Transaction) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	existing, ok := r.db.transactions[t.ID]
+	if !ok || existing.DeletedAt != nil || existing.Version != t.Version {
+		return sql.ErrNoRows
+	}
+	previous := *existing
+	r.db.onRollback(ctx, func() { *existing = previous })
+	existing.Amount = decimal(t.Amount)
+	existing.Description = t.Description
+	existing.Type = t.Type
+	existing.UpdatedAt = timestamp(t.UpdatedAt)
+	existing.Version++
+	t.Version = existing.Version
+	return nil
+}
+
+func (r *TxRepo) Delete(ctx context.Context, id int64, at time.Time) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if t, ok := r.db.transactions[id]; ok && t.DeletedAt == nil {
+		t.DeletedAt = timestampPtr(&at)
+		r.db.onRollback(ctx, func() { t.DeletedAt = nil })
+	}
+	return nil
+}
+
+func (r *TxRepo) FindDeletedByUserID(ctx context.Context, userID int64, page domain.Page) ([]*domain.Transaction, error) {
+	transactions := r.find(userID, true)
+	sort.Slice(transactions, func(i, j int) bool {
+		a, b := transactions[i], transactions[j]
+		if !a.DeletedAt.Equal(*b.DeletedAt) {
+			return a.DeletedAt.After(*b.DeletedAt)
+		}
+		return a.ID > b.ID
+	})
+	return paged(transactions, page), nil
+}
+
+func (r *TxRepo) CountDeletedByUserID(ctx context.Context, userID int64) (int, error) {
+	return len(r.find(userID, true)), nil
+}
+
+func (r *TxRepo) FindDeletedByID(ctx context.Context, id, userID int64) (*domain.Transaction, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	t, ok := r.db.transactions[id]
+	if !ok || t.UserID != userID || t.DeletedAt == nil {
+		return nil, sql.ErrNoRows
+	}
+	return copyTransaction(t), nil
+}
+
+func (r *TxRepo) Restore(ctx context.Context, id, userID int64) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	t, ok := r.db.transactions[id]
+	if !ok || t.UserID != userID || t.DeletedAt == nil {
+		return sql.ErrNoRows
+	}
+	deletedAt := t.DeletedAt
+	t.DeletedAt = nil
+	r.db.onRollback(ctx, func() { t.DeletedAt = deletedAt })
+	return nil
+}
+
+func (r *TxRepo) Purge(ctx context.Context, cutoff time.Time, limit int) ([]*domain.Transaction, error) {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	var ids []int64
+	for id, t := range r.db.transactions {
+		if t.DeletedAt != nil && t.DeletedAt.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var purged []*domain.Transaction
+	for _, id := range ids[:min(limit, len(ids))] {
+		t := r.db.transactions[id]
+		delete(r.db.transactions, id)
+		r.db.onRollback(ctx, func() { r.db.transactions[id] = t })
+		purged = append(purged, copyTransaction(t))
+	}
+	return purged, nil
+}
+
+// find copies the user's transactions that are in the trash or not.
+func (r *TxRepo) find(userID int64, deleted bool) []*domain.Transaction {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	var transactions []*domain.Transaction
+	for _, t := range r.db.transactions {
+		if t.UserID == userID && (t.DeletedAt != nil) == deleted {
+			transactions = append(transactions, copyTransaction(t))
+		}
+	}
+	return transactions
+}
+
+// paged returns the part of ts that page selects.
+func paged(ts []*domain.Transaction, page domain.Page) []*domain.Transaction {
+	if page.Limit <= 0 {
+		return ts
+	}
+	ts = ts[min(page.Offset, len(ts)):]
+	return ts[:min(page.Limit, len(ts))]
+}
+
+func copyTransaction(t *domain.Transaction) *domain.Transaction {
+	c := *t
+	c.DeletedAt = copyTime(t.DeletedAt)
+	return &c
+}
+
+func stored(t *domain.Transaction) *domain.Transaction {
+	s := *t
+	s.Amount = decimal(t.Amount)
+	s.CreatedAt = timestamp(t.CreatedAt)
+	s.UpdatedAt = timestamp(t.UpdatedAt)
+	s.DeletedAt = nil
+	return &s
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/user"
+)
+
+type UserRepo struct {
+	db *DB
+}
+
+func NewUserRepo(db *DB) *UserRepo {
+	return &UserRepo{db: db}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *domain.User) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if _, taken := r.db.emails[emailKey(u.Email)]; taken {
+		return domain.ErrEmailTaken
+	}
+	r.db.nextUserID++
+	u.ID = r.db.nextUserID
+	stored := *u
+	stored.CreatedAt = timestamp(u.CreatedAt)
+	r.db.users[u.ID] = &stored
+	r.db.emails[emailKey(u.Email)] = u.ID
+	id := u.ID
+	r.db.onRollback(ctx, func() {
+		delete(r.db.users, id)
+		delete(r.db.emails, emailKey(stored.Email))
+	})
+	return nil
+}
+
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	id, ok := r.db.emails[emailKey(email)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	u := *r.db.users[id]
+	return &u, nil
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, id int64) (*domain.User, error) {
+	r.db.mu.RLock()
+	defer r.db.mu.RUnlock()
+	stored, ok := r.db.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	u := *stored
+	return &u, nil
+}
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int64, password string) error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	if u, ok := r.db.users[id]; ok {
+		previous := u.Password
+		u.Password = password
+		r.db.onRollback(ctx, func() { u.Password = previous })
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Package migration embeds the versioned SQL migrations of each database
+// backend. Files are named <version>_<name>.sql and applied in version order;
+// the highest version is the schema version the code expects.
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// Markers around a statement whose body contains semicolons, such as a
+// trigger or a function; everything between them is one statement.
+const (
+	statementBegin = "-- statement begin"
+	statementEnd   = "-- statement end"
+)
+
+// Statements splits the migration into single statements, for drivers that
+// do not execute several statements per call. Outside of statementBegin and
+// statementEnd markers, statements end at every semicolon.
+func (m Migration) Statements() []string {
+	var stmts []string
+	add := func(stmt string) {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	var plain, block strings.Builder
+	inBlock := false
+	for _, line := range strings.SplitAfter(m.SQL, "\n") {
+		switch strings.TrimSpace(line) {
+		case statementBegin:
+			for _, stmt := range strings.Split(plain.String(), ";") {
+				add(stmt)
+			}
+			plain.Reset()
+			inBlock = true
+		case statementEnd:
+			add(block.String())
+			block.Reset()
+			inBlock = false
+		default:
+			if inBlock {
+				block.WriteString(line)
+			} else {
+				plain.WriteString(line)
+			}
+		}
+	}
+	add(block.String())
+	for _, stmt := range strings.Split(plain.String(), ";") {
+		add(stmt)
+	}
+	return stmts
+}
+
+// Load returns the migrations of dialect, e.g. "mysql", sorted by version.
+func Load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %q: %w", dialect, err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	seen := make(map[int]string)
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".sql")
+		v, label, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(v)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", e.Name())
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrations %q and %q share version %d", other, e.Name(), version)
+		}
+		seen[version] = e.Name()
+
+		body, err := fs.ReadFile(files, path.Join(dialect, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: label, SQL: string(body)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Latest returns the highest version in migrations, or 0 if there are none.
+func Latest(migrations []Migration) int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Apply runs the migrations of dialect that are newer than the version
+// recorded in schema_migrations. Statements are executed one at a time; the
+// drivers do not allow several per Exec by default. They all run on one
+// connection, so session variables set by a statement carry over.
+func Apply(ctx context.Context, db *sql.DB, dialect string) error {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get a connection for migrations: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	current, err := version(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	record := "INSERT INTO schema_migrations (version, name) VALUES (?, ?)"
+	if dialect == "postgres" {
+		record = "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)"
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		for _, stmt := range m.Statements() {
+			if _, err := conn.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+			}
+		}
+		if _, err := conn.ExecContext(ctx, record, m.Version, m.Name); err != nil {
+			return fmt.Errorf("failed to record migration %d: %w", m.Version, err)
+		}
+		log.Printf("[DB] Applied migration %d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
+// Version returns the highest applied migration version.
+func Version(ctx context.Context, db *sql.DB) (int, error) {
+	return version(ctx, db)
+}
+
+// rowQuerier is a *sql.DB or a *sql.Conn.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func version(ctx context.Context, db rowQuerier) (int, error) {
+	var version sql.NullInt64
+	if err := db.QueryRowContext(ctx, "SELECT MAX(version) FROM schema_migrations").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return int(version.Int64), nil
+}
@@ -0,0 +1,48 @@
+CREATE TABLE IF NOT EXISTS users (
+id BIGINT AUTO_INCREMENT PRIMARY KEY,
+name VARCHAR(255) NOT NULL,
+email VARCHAR(255) NOT NULL UNIQUE,
+password VARCHAR(255) NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+id BIGINT AUTO_INCREMENT PRIMARY KEY,
+user_id BIGINT NOT NULL,
+amount DECIMAL(10,2) NOT NULL,
+description VARCHAR(500) NOT NULL,
+type ENUM('income', 'expense') NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+INDEX idx_user_id (user_id),
+INDEX idx_created_at (created_at)
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+id BIGINT AUTO_INCREMENT PRIMARY KEY,
+user_id BIGINT NOT NULL,
+name VARCHAR(100) NOT NULL,
+prefix VARCHAR(16) NOT NULL UNIQUE,
+key_hash CHAR(64) NOT NULL,
+scopes VARCHAR(500) NOT NULL DEFAULT '',
+expires_at TIMESTAMP NULL DEFAULT NULL,
+last_used_at TIMESTAMP NULL DEFAULT NULL,
+revoked_at TIMESTAMP NULL DEFAULT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+INDEX idx_api_keys_user_id (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+id CHAR(32) PRIMARY KEY,
+user_id BIGINT NOT NULL,
+ip VARCHAR(45) NOT NULL,
+user_agent VARCHAR(255) NOT NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+last_seen_at TIMESTAMP NULL DEFAULT NULL,
+expires_at TIMESTAMP NULL DEFAULT NULL,
+revoked_at TIMESTAMP NULL DEFAULT NULL,
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+INDEX idx_sessions_user_id (user_id)
+);
@@ -0,0 +1,24 @@
+-- MySQL has no ADD COLUMN IF NOT EXISTS, and DDL is not transactional, so
+-- each change checks the schema first: a migration that failed half way can
+-- be run again.
+SET @ddl = IF(
+(SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'transactions' AND column_name = 'deleted_at') = 0,
+'ALTER TABLE transactions ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL',
+'SELECT 1');
+
+PREPARE migrate FROM @ddl;
+
+EXECUTE migrate;
+
+DEALLOCATE PREPARE migrate;
+
+SET @ddl = IF(
+(SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'transactions' AND index_name = 'idx_transactions_deleted_at') = 0,
+'CREATE INDEX idx_transactions_deleted_at ON transactions (deleted_at)',
+'SELECT 1');
+
+PREPARE migrate FROM @ddl;
+
+EXECUTE migrate;
+
+DEALLOCATE PREPARE migrate;
@@ -0,0 +1,57 @@
+-- Before and after are kept as text, not JSON: the JSON type normalizes the
+-- document, which would change the bytes the record's hash covers.
+CREATE TABLE IF NOT EXISTS audit_log (
+id BIGINT AUTO_INCREMENT PRIMARY KEY,
+actor_id BIGINT NOT NULL DEFAULT 0,
+action VARCHAR(16) NOT NULL,
+entity_type VARCHAR(32) NOT NULL,
+entity_id BIGINT NOT NULL,
+before_data TEXT NULL,
+after_data TEXT NULL,
+ip VARCHAR(45) NOT NULL DEFAULT '',
+request_id VARCHAR(128) NOT NULL DEFAULT '',
+created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+prev_hash VARCHAR(64) NOT NULL,
+hash VARCHAR(64) NOT NULL,
+INDEX idx_audit_log_entity (entity_type, entity_id),
+INDEX idx_audit_log_actor (actor_id, created_at),
+INDEX idx_audit_log_created_at (created_at)
+);
+
+-- The single row holds the hash of the newest record. Writers lock it, so
+-- records are chained in the order they commit.
+CREATE TABLE IF NOT EXISTS audit_chain (
+id INT PRIMARY KEY,
+last_hash VARCHAR(64) NOT NULL
+);
+
+-- MySQL DDL is not transactional: if a trigger below fails, the statements
+-- before it stay applied. Every statement can therefore be run again.
+INSERT IGNORE INTO audit_chain (id, last_hash) VALUES (1, '');
+
+DROP TRIGGER IF EXISTS audit_log_no_update;
+
+-- statement begin
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log FOR EACH ROW
+BEGIN
+SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+END;
+-- statement end
+
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+
+-- statement begin
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log FOR EACH ROW
+BEGIN
+SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only';
+END;
+-- statement end
+
+DROP TRIGGER IF EXISTS audit_chain_no_delete;
+
+-- statement begin
+CREATE TRIGGER audit_chain_no_delete BEFORE DELETE ON audit_chain FOR EACH ROW
+BEGIN
+SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_chain is append-only';
+END;
+-- statement end
@@ -0,0 +1,11 @@
+-- Checks the schema first so it can be run again, like 0002.
+SET @ddl = IF(
+(SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'transactions' AND column_name = 'version') = 0,
+'ALTER TABLE transactions ADD COLUMN version BIGINT NOT NULL DEFAULT 1',
+'SELECT 1');
+
+PREPARE migrate FROM @ddl;
+
+EXECUTE migrate;
+
+DEALLOCATE PREPARE migrate;
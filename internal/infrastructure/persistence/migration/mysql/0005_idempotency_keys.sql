@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+user_id BIGINT NOT NULL,
+idempotency_key VARCHAR(255) NOT NULL,
+request_hash CHAR(64) NOT NULL,
+status INT NOT NULL DEFAULT 0,
+response_headers TEXT NULL,
+response_body MEDIUMBLOB NULL,
+created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+expires_at TIMESTAMP NULL DEFAULT NULL,
+PRIMARY KEY (user_id, idempotency_key),
+FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+INDEX idx_idempotency_keys_expires_at (expires_at)
+);
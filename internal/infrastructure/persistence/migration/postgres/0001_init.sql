@@ -0,0 +1,52 @@
+CREATE TABLE IF NOT EXISTS users (
+id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+name VARCHAR(255) NOT NULL,
+email VARCHAR(255) NOT NULL,
+password VARCHAR(255) NOT NULL,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Emails are unique regardless of case, as with MySQL's default collation.
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (lower(email));
+
+CREATE TABLE IF NOT EXISTS transactions (
+id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+amount NUMERIC(10,2) NOT NULL,
+description VARCHAR(500) NOT NULL,
+type VARCHAR(10) NOT NULL CHECK (type IN ('income', 'expense')),
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_user_id ON transactions (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_created_at ON transactions (created_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+name VARCHAR(100) NOT NULL,
+prefix VARCHAR(16) NOT NULL UNIQUE,
+key_hash VARCHAR(64) NOT NULL,
+scopes VARCHAR(500) NOT NULL DEFAULT '',
+expires_at TIMESTAMPTZ NULL DEFAULT NULL,
+last_used_at TIMESTAMPTZ NULL DEFAULT NULL,
+revoked_at TIMESTAMPTZ NULL DEFAULT NULL,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS sessions (
+id VARCHAR(32) PRIMARY KEY,
+user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+ip VARCHAR(45) NOT NULL,
+user_agent VARCHAR(255) NOT NULL,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+last_seen_at TIMESTAMPTZ NULL DEFAULT NULL,
+expires_at TIMESTAMPTZ NULL DEFAULT NULL,
+revoked_at TIMESTAMPTZ NULL DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ NULL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions (deleted_at);
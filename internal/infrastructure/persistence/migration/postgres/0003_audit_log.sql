@@ -0,0 +1,51 @@
+-- Before and after are kept as text, not jsonb: jsonb normalizes the
+-- document, which would change the bytes the record's hash covers.
+CREATE TABLE IF NOT EXISTS audit_log (
+id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+actor_id BIGINT NOT NULL DEFAULT 0,
+action VARCHAR(16) NOT NULL,
+entity_type VARCHAR(32) NOT NULL,
+entity_id BIGINT NOT NULL,
+before_data TEXT NULL,
+after_data TEXT NULL,
+ip VARCHAR(45) NOT NULL DEFAULT '',
+request_id VARCHAR(128) NOT NULL DEFAULT '',
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+prev_hash VARCHAR(64) NOT NULL,
+hash VARCHAR(64) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity_type, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at);
+
+-- The single row holds the hash of the newest record. Writers lock it, so
+-- records are chained in the order they commit.
+CREATE TABLE IF NOT EXISTS audit_chain (
+id INT PRIMARY KEY,
+last_hash VARCHAR(64) NOT NULL
+);
+
+INSERT INTO audit_chain (id, last_hash) VALUES (1, '') ON CONFLICT (id) DO NOTHING;
+
+-- statement begin
+CREATE OR REPLACE FUNCTION audit_append_only() RETURNS trigger AS $$
+BEGIN
+RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+-- statement end
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_append_only();
+
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_append_only();
+
+CREATE TRIGGER audit_chain_no_delete BEFORE DELETE ON audit_chain
+FOR EACH ROW EXECUTE FUNCTION audit_append_only();
+
+CREATE TRIGGER audit_chain_no_truncate BEFORE TRUNCATE ON audit_chain
+FOR EACH STATEMENT EXECUTE FUNCTION audit_append_only();
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+idempotency_key VARCHAR(255) NOT NULL,
+request_hash VARCHAR(64) NOT NULL,
+status INTEGER NOT NULL DEFAULT 0,
+response_headers TEXT NULL,
+response_body BYTEA NULL,
+created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+expires_at TIMESTAMPTZ NULL DEFAULT NULL,
+PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -1,20 +0,0 @@
-CREATE TABLE IF NOT EXISTS users (
-id BIGINT AUTO_INCREMENT PRIMARY KEY,
-name VARCHAR(255) NOT NULL,
-email VARCHAR(255) NOT NULL UNIQUE,
-password VARCHAR(255) NOT NULL,
-created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-);
-
-CREATE TABLE IF NOT EXISTS transactions (
-id BIGINT AUTO_INCREMENT PRIMARY KEY,
-user_id BIGINT NOT NULL,
-amount DECIMAL(10,2) NOT NULL,
-description VARCHAR(500) NOT NULL,
-type ENUM('income', 'expense') NOT NULL,
-created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-INDEX idx_user_id (user_id),
-INDEX idx_created_at (created_at)
-);
\ No newline at end of file
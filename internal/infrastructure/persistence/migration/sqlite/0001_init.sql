@@ -0,0 +1,49 @@
+CREATE TABLE IF NOT EXISTS users (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+name TEXT NOT NULL,
+email TEXT NOT NULL UNIQUE COLLATE NOCASE,
+password TEXT NOT NULL,
+created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+amount NUMERIC NOT NULL,
+description TEXT NOT NULL,
+type TEXT NOT NULL CHECK (type IN ('income', 'expense')),
+created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_id ON transactions (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_created_at ON transactions (created_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+name TEXT NOT NULL,
+prefix TEXT NOT NULL UNIQUE,
+key_hash TEXT NOT NULL,
+scopes TEXT NOT NULL DEFAULT '',
+expires_at DATETIME NULL DEFAULT NULL,
+last_used_at DATETIME NULL DEFAULT NULL,
+revoked_at DATETIME NULL DEFAULT NULL,
+created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS sessions (
+id TEXT PRIMARY KEY,
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+ip TEXT NOT NULL,
+user_agent TEXT NOT NULL,
+created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+last_seen_at DATETIME NULL DEFAULT NULL,
+expires_at DATETIME NULL DEFAULT NULL,
+revoked_at DATETIME NULL DEFAULT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
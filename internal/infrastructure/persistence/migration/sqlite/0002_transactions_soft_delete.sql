@@ -0,0 +1,3 @@
+ALTER TABLE transactions ADD COLUMN deleted_at DATETIME NULL DEFAULT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_deleted_at ON transactions (deleted_at);
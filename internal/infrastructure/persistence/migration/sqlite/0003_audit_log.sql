@@ -0,0 +1,50 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+id INTEGER PRIMARY KEY AUTOINCREMENT,
+actor_id INTEGER NOT NULL DEFAULT 0,
+action TEXT NOT NULL,
+entity_type TEXT NOT NULL,
+entity_id INTEGER NOT NULL,
+before_data TEXT NULL,
+after_data TEXT NULL,
+ip TEXT NOT NULL DEFAULT '',
+request_id TEXT NOT NULL DEFAULT '',
+created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+prev_hash TEXT NOT NULL,
+hash TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log (entity_type, entity_id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log (actor_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log (created_at);
+
+-- The single row holds the hash of the newest record. Transactions take the
+-- write lock up front (see DSN), so records are chained in commit order.
+CREATE TABLE IF NOT EXISTS audit_chain (
+id INTEGER PRIMARY KEY,
+last_hash TEXT NOT NULL
+);
+
+INSERT OR IGNORE INTO audit_chain (id, last_hash) VALUES (1, '');
+
+-- statement begin
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+-- statement end
+
+-- statement begin
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+-- statement end
+
+-- statement begin
+CREATE TRIGGER IF NOT EXISTS audit_chain_no_delete BEFORE DELETE ON audit_chain
+BEGIN
+SELECT RAISE(ABORT, 'audit_chain is append-only');
+END;
+-- statement end
@@ -0,0 +1 @@
+ALTER TABLE transactions ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
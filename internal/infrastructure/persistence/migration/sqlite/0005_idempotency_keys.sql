@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+idempotency_key TEXT NOT NULL,
+request_hash TEXT NOT NULL,
+status INTEGER NOT NULL DEFAULT 0,
+response_headers TEXT NULL,
+response_body BLOB NULL,
+created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+expires_at DATETIME NULL DEFAULT NULL,
+PRIMARY KEY (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,146 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/audit"
+)
+
+type AuditRepo struct {
+	db dbtx
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: traced(db)}
+}
+
+const auditColumns = `id, actor_id, action, entity_type, entity_id, before_data, after_data, ip, request_id, created_at, prev_hash, hash`
+
+func (r *AuditRepo) Head(ctx context.Context) (string, error) {
+	q := `SELECT last_hash FROM audit_chain WHERE id = 1 FOR UPDATE`
+	var head string
+	if err := r.db.QueryRowContext(ctx, q).Scan(&head); err != nil {
+		return "", err
+	}
+	return head, nil
+}
+
+func (r *AuditRepo) Append(ctx context.Context, a *domain.Record) error {
+	q := `INSERT INTO audit_log (actor_id, action, entity_type, entity_id, before_data, after_data, ip, request_id, created_at, prev_hash, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	res, err := r.db.ExecContext(ctx, q, a.ActorID, a.Action, a.EntityType, a.EntityID, nullJSON(a.Before), nullJSON(a.After), a.IP, a.RequestID, a.CreatedAt, a.PrevHash, a.Hash)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.ID = id
+
+	q = `UPDATE audit_chain SET last_hash = ? WHERE id = 1`
+	_, err = r.db.ExecContext(ctx, q, a.Hash)
+	return translateError(ctx, err)
+}
+
+func (r *AuditRepo) Find(ctx context.Context, f domain.Filter) ([]*domain.Record, error) {
+	where, args := auditWhere(f)
+	q := `SELECT ` + auditColumns + ` FROM audit_log` + where + ` ORDER BY id DESC`
+	if f.Limit > 0 {
+		q, args = q+` LIMIT ? OFFSET ?`, append(args, f.Limit, f.Offset)
+	}
+	return r.query(ctx, q, args...)
+}
+
+func (r *AuditRepo) Count(ctx context.Context, f domain.Filter) (int, error) {
+	where, args := auditWhere(f)
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&n)
+	return n, err
+}
+
+// auditWhere returns the WHERE clause selecting the records matching f,
+// or "" when f matches everything.
+func auditWhere(f domain.Filter) (string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	if f.ActorID != 0 {
+		where, args = append(where, "actor_id = ?"), append(args, f.ActorID)
+	}
+	if f.EntityType != "" {
+		where, args = append(where, "entity_type = ?"), append(args, f.EntityType)
+	}
+	if f.EntityID != 0 {
+		where, args = append(where, "entity_id = ?"), append(args, f.EntityID)
+	}
+	if !f.From.IsZero() {
+		where, args = append(where, "created_at >= ?"), append(args, f.From)
+	}
+	if !f.To.IsZero() {
+		where, args = append(where, "created_at < ?"), append(args, f.To)
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return ` WHERE ` + strings.Join(where, " AND "), args
+}
+
+func (r *AuditRepo) Chain(ctx context.Context, afterID int64, limit int) ([]*domain.Record, error) {
+	q := `SELECT ` + auditColumns + ` FROM audit_log WHERE id > ? ORDER BY id LIMIT ?`
+	return r.query(ctx, q, afterID, limit)
+}
+
+func (r *AuditRepo) query(ctx context.Context, q string, args ...any) ([]*domain.Record, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*domain.Record
+	for rows.Next() {
+		a, err := scanAuditRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func scanAuditRecord(row rowScanner) (*domain.Record, error) {
+	var (
+		a             domain.Record
+		before, after sql.NullString
+	)
+	if err := row.Scan(&a.ID, &a.ActorID, &a.Action, &a.EntityType, &a.EntityID, &before, &after, &a.IP, &a.RequestID, &a.CreatedAt, &a.PrevHash, &a.Hash); err != nil {
+		return nil, err
+	}
+	a.Before = rawJSON(before)
+	a.After = rawJSON(after)
+	return &a, nil
+}
+
+// nullJSON stores an empty side of a diff as NULL.
+func nullJSON(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
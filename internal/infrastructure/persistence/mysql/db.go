@@ -1,95 +1,127 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
+	sqldriver "database/sql/driver"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
+	"net"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
+	driver "github.com/go-sql-driver/mysql"
 	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/migration"
 )
 
-var DB *sql.DB
-
-// Connect membuka koneksi ke database MySQL menggunakan konfigurasi dari config package.
-func Connect() (*sql.DB, error) {
-	cfg := config.Get()
-	if cfg == nil {
-		return nil, fmt.Errorf("config is not loaded")
-	}
-
-	var dsn string
-	if cfg.Database.DSN != "" {
-		dsn = cfg.Database.DSN
-	} else {
-		// format DSN: user:password@tcp(host:port)/dbname?parseTime=true&loc=Local
-		dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&loc=Local",
-			cfg.Database.User,
-			cfg.Database.Password,
-			cfg.Database.Host,
-			cfg.Database.Port,
-			cfg.Database.Name,
-		)
+// Connect membuka koneksi ke database MySQL menggunakan cfg.
+// The first ping is retried with exponential backoff until it succeeds, the
+// retries are used up or ctx is done. It returns the primary and a router
+// over it and the read replicas; closing the router closes both.
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, *Router, error) {
+	dc, err := DriverConfig(cfg)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	db, err := sql.Open("mysql", dsn)
+	connector, err := driver.NewConnector(dc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open DB: %w", err)
+		return nil, nil, fmt.Errorf("failed to open DB: %w", err)
 	}
-
-	// Optional: set connection pool config
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(10)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	db := sql.OpenDB(connector)
+	persistence.ConfigurePool(db, cfg)
 
 	// Test connection
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to connect DB: %w", err)
+	if err := persistence.PingWithRetry(ctx, db, cfg); err != nil {
+		db.Close()
+		return nil, nil, err
 	}
 
 	log.Println("[DB] Connected to MySQL successfully")
 
 	// Run migrations
-	if err := runMigrations(db); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	DB = db
-	return db, nil
-}
+	replicas, err := openReplicas(dc, cfg)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
 
-// Get returns the global *sql.DB instance (after Connect() is called)
-func Get() *sql.DB {
-	return DB
+	sticky := time.Duration(cfg.ReplicaStickySeconds) * time.Second
+	return db, NewRouter(db, replicas, sticky), nil
 }
 
-// Close menutup koneksi database
-func Close() error {
-	if DB != nil {
-		return DB.Close()
+// openReplicas opens the replica pools without connecting; the router's
+// health checks decide when they take reads. Each replica connects like the
+// primary, with its credentials, TLS settings and timeouts, to another
+// address.
+func openReplicas(primary *driver.Config, cfg config.DatabaseConfig) ([]*sql.DB, error) {
+	var replicas []*sql.DB
+	for i, addr := range cfg.Replicas {
+		connector, err := replicaConnector(primary, addr)
+		if err != nil {
+			for _, db := range replicas {
+				db.Close()
+			}
+			return nil, fmt.Errorf("invalid database replica %d: %w", i, err)
+		}
+		db := sql.OpenDB(connector)
+		persistence.ConfigurePool(db, cfg)
+		replicas = append(replicas, db)
 	}
-	return nil
+	return replicas, nil
 }
 
-// runMigrations executes the database schema migration
-func runMigrations(db *sql.DB) error {
-	log.Println("[DB] Running migrations...")
-
-	// Read schema file
-	schemaPath := filepath.Join("internal", "infrastructure", "persistence", "migration", "schema.sql")
-	schema, err := os.ReadFile(schemaPath)
+// replicaConnector connects to addr, a host or host:port, with the settings
+// of primary. A missing port is the primary's.
+func replicaConnector(primary *driver.Config, addr string) (sqldriver.Connector, error) {
+	primaryHost, primaryPort, err := net.SplitHostPort(primary.Addr)
 	if err != nil {
-		return fmt.Errorf("failed to read schema file: %w", err)
+		return nil, fmt.Errorf("primary address %q: %w", primary.Addr, err)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		host, port = addr, primaryPort
+	}
+	if host == "" {
+		return nil, fmt.Errorf("replica address %q has no host", addr)
 	}
 
-	// Execute schema
-	if _, err := db.Exec(string(schema)); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
+	dc := primary.Clone()
+	dc.Addr = net.JoinHostPort(host, port)
+	// The driver verifies the primary's host name unless told otherwise;
+	// each replica must present its own.
+	if dc.TLS != nil && dc.TLS.ServerName == primaryHost {
+		dc.TLS.ServerName = ""
 	}
+	return driver.NewConnector(dc)
+}
 
+// Migrate applies the embedded migrations that are newer than the version
+// recorded in schema_migrations.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	log.Println("[DB] Running migrations...")
+	if err := migration.Apply(ctx, db, "mysql"); err != nil {
+		return err
+	}
 	log.Println("[DB] Migrations completed successfully")
 	return nil
 }
+
+// SchemaVersion returns the highest applied migration version.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	return migration.Version(ctx, db)
+}
+
+// ExpectedSchemaVersion is the version of the newest embedded migration.
+func ExpectedSchemaVersion() (int, error) {
+	migrations, err := migration.Load("mysql")
+	if err != nil {
+		return 0, err
+	}
+	return migration.Latest(migrations), nil
+}
@@ -0,0 +1,54 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/rs/zerolog"
+)
+
+// MySQL server error numbers we translate.
+const (
+	errDuplicateEntry   = 1062
+	errRowIsReferenced  = 1451
+	errNoReferencedRow  = 1452
+	errRowIsReferenced2 = 1217
+	errNoReferencedRow2 = 1216
+)
+
+var (
+	ErrDuplicateEntry   = persistence.ErrDuplicateEntry
+	ErrStillReferenced  = persistence.ErrStillReferenced
+	ErrReferenceMissing = persistence.ErrReferenceMissing
+)
+
+// translateError turns constraint violations into AppErrors so raw MySQL
+// messages never reach clients; the raw message is logged to the request
+// logger instead. Other errors, including sql.ErrNoRows, are returned
+// unchanged.
+func translateError(ctx context.Context, err error) error {
+	var myErr *driver.MySQLError
+	if !errors.As(err, &myErr) {
+		return err
+	}
+	zerolog.Ctx(ctx).Debug().
+		Err(err).
+		Uint16("mysql_error", myErr.Number).
+		Msg("MySQL: statement failed")
+	switch myErr.Number {
+	case errDuplicateEntry:
+		return ErrDuplicateEntry.Wrap(err)
+	case errRowIsReferenced, errRowIsReferenced2:
+		return ErrStillReferenced.Wrap(err)
+	case errNoReferencedRow, errNoReferencedRow2:
+		return ErrReferenceMissing.Wrap(err)
+	}
+	return err
+}
+
+func isDuplicateEntry(err error) bool {
+	var myErr *driver.MySQLError
+	return errors.As(err, &myErr) && myErr.Number == errDuplicateEntry
+}
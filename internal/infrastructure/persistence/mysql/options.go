@@ -0,0 +1,123 @@
+package mysql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+// Defaults for zero values in config.DatabaseConfig.
+const (
+	DefaultMaxOpenConns      = persistence.DefaultMaxOpenConns
+	DefaultMaxIdleConns      = persistence.DefaultMaxIdleConns
+	DefaultConnMaxLifetime   = persistence.DefaultConnMaxLifetime
+	DefaultConnectTimeout    = persistence.DefaultConnectTimeout
+	DefaultIOTimeout         = 30 * time.Second
+	DefaultCharset           = "utf8mb4"
+	DefaultConnectMaxBackoff = persistence.DefaultConnectMaxBackoff
+)
+
+// TLS modes accepted in config.DatabaseConfig.TLSMode.
+const (
+	TLSDisabled       = "disabled"
+	TLSPreferred      = "preferred"
+	TLSRequired       = "required"
+	TLSVerifyCA       = "verify_ca"
+	TLSVerifyIdentity = "verify_identity"
+)
+
+// DriverConfig builds the driver configuration for cfg. A DSN is parsed and
+// used as is; otherwise the connection is assembled from the individual
+// fields, with parseTime and the local time zone as before.
+func DriverConfig(cfg config.DatabaseConfig) (*driver.Config, error) {
+	if cfg.DSN != "" {
+		dc, err := driver.ParseDSN(cfg.DSN)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database DSN: %w", err)
+		}
+		return dc, nil
+	}
+
+	dc := driver.NewConfig()
+	dc.User = cfg.User
+	dc.Passwd = cfg.Password
+	dc.Net = "tcp"
+	dc.Addr = net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	dc.DBName = cfg.Name
+	dc.ParseTime = true
+	dc.Loc = time.Local
+	dc.Timeout = persistence.Seconds(cfg.ConnectTimeoutSeconds, DefaultConnectTimeout)
+	dc.ReadTimeout = persistence.Seconds(cfg.ReadTimeoutSeconds, DefaultIOTimeout)
+	dc.WriteTimeout = persistence.Seconds(cfg.WriteTimeoutSeconds, DefaultIOTimeout)
+
+	charset := cfg.Charset
+	if charset == "" {
+		charset = DefaultCharset
+	}
+	if err := dc.Apply(driver.Charset(charset, cfg.Collation)); err != nil {
+		return nil, err
+	}
+
+	switch cfg.TLSMode {
+	case "", TLSDisabled:
+	case TLSPreferred:
+		dc.TLSConfig = "preferred"
+	case TLSRequired:
+		dc.TLSConfig = "skip-verify"
+	case TLSVerifyCA, TLSVerifyIdentity:
+		tc, err := verifyingTLS(cfg.TLSMode, cfg.TLSCAFile)
+		if err != nil {
+			return nil, err
+		}
+		dc.TLS = tc
+	default:
+		return nil, fmt.Errorf("unknown database TLS mode %q", cfg.TLSMode)
+	}
+	return dc, nil
+}
+
+// verifyingTLS trusts caFile, or the system roots when it is empty. For
+// verify_identity the driver fills in the server name from the address.
+func verifyingTLS(mode, caFile string) (*tls.Config, error) {
+	var roots *x509.CertPool
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read database CA: %w", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+	}
+	if mode == TLSVerifyIdentity {
+		return &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}, nil
+	}
+
+	// verify_ca checks the chain but not the host name, which the standard
+	// verification cannot skip on its own.
+	return &tls.Config{
+		RootCAs:            roots,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return fmt.Errorf("database server sent no certificate")
+			}
+			opts := x509.VerifyOptions{Roots: roots, Intermediates: x509.NewCertPool()}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+	}, nil
+}
@@ -0,0 +1,167 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/pkg/actor"
+)
+
+// Defaults for zero values in config.DatabaseConfig.
+const (
+	DefaultReplicaSticky        = 5 * time.Second
+	DefaultReplicaCheckInterval = 5 * time.Second
+)
+
+// maxStickyUsers bounds the write log; expired entries are dropped when it
+// is reached.
+const maxStickyUsers = 10000
+
+// Router sends writes to the primary and reads to the replicas, round
+// robin. After a user writes, their reads stay on the primary for the sticky
+// window, so they see their own changes despite replication lag. Replicas
+// that fail a health check are skipped until they pass again; with no
+// healthy replica, reads go to the primary.
+type Router struct {
+	db       *sql.DB
+	primary  dbtx
+	replicas []*replica
+	sticky   time.Duration
+	next     atomic.Uint64
+
+	mu         sync.Mutex
+	lastWrites map[int64]time.Time
+}
+
+type replica struct {
+	db      *sql.DB
+	conn    dbtx
+	healthy atomic.Bool
+}
+
+// NewRouter routes between primary and replicas. Replicas start unhealthy
+// and take reads after the first successful CheckReplicas. sticky <= 0
+// uses DefaultReplicaSticky.
+func NewRouter(primary *sql.DB, replicas []*sql.DB, sticky time.Duration) *Router {
+	if sticky <= 0 {
+		sticky = DefaultReplicaSticky
+	}
+	r := &Router{db: primary, primary: traced(primary), sticky: sticky, lastWrites: make(map[int64]time.Time)}
+	for _, db := range replicas {
+		r.replicas = append(r.replicas, &replica{db: db, conn: traced(db)})
+	}
+	return r
+}
+
+// Close closes the replicas and the primary.
+func (r *Router) Close() error {
+	var errs []error
+	for _, rep := range r.replicas {
+		errs = append(errs, rep.db.Close())
+	}
+	errs = append(errs, r.db.Close())
+	return errors.Join(errs...)
+}
+
+// Writer returns the primary and starts the sticky window of the user in
+// ctx, if any.
+func (r *Router) Writer(ctx context.Context) dbtx {
+	if id, ok := actor.UserID(ctx); ok && len(r.replicas) > 0 {
+		now := time.Now()
+		r.mu.Lock()
+		if len(r.lastWrites) >= maxStickyUsers {
+			for user, at := range r.lastWrites {
+				if now.Sub(at) > r.sticky {
+					delete(r.lastWrites, user)
+				}
+			}
+		}
+		r.lastWrites[id] = now
+		r.mu.Unlock()
+	}
+	return r.primary
+}
+
+// Reader returns a healthy replica, or the primary when ctx is in a
+// transaction of the primary, the user in ctx wrote within the sticky window
+// or no replica is healthy.
+func (r *Router) Reader(ctx context.Context) dbtx {
+	if len(r.replicas) == 0 || persistence.InTx(ctx, r.db) || r.isSticky(ctx) {
+		return r.primary
+	}
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		rep := r.replicas[(int(start)+i)%len(r.replicas)]
+		if rep.healthy.Load() {
+			return rep.conn
+		}
+	}
+	return r.primary
+}
+
+func (r *Router) isSticky(ctx context.Context) bool {
+	id, ok := actor.UserID(ctx)
+	if !ok {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.lastWrites[id]
+	return ok && time.Since(at) <= r.sticky
+}
+
+// CheckReplicas pings every replica and records whether it answered.
+func (r *Router) CheckReplicas(ctx context.Context) {
+	for i, rep := range r.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, DefaultConnectTimeout)
+		err := rep.db.PingContext(pingCtx)
+		cancel()
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf("[DB] Replica %d is healthy", i)
+			} else {
+				log.Printf("[DB] Replica %d is unhealthy, reads fall back: %v", i, err)
+			}
+		}
+	}
+}
+
+// HealthyReplicas returns how many replicas currently take reads.
+func (r *Router) HealthyReplicas() int {
+	n := 0
+	for _, rep := range r.replicas {
+		if rep.healthy.Load() {
+			n++
+		}
+	}
+	return n
+}
+
+// Run checks the replicas immediately and then every interval until ctx is
+// done. It returns at once when there are no replicas.
+func (r *Router) Run(ctx context.Context, interval time.Duration) {
+	if len(r.replicas) == 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultReplicaCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.CheckReplicas(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/session"
+)
+
+type SessionRepo struct {
+	db dbtx
+}
+
+func NewSessionRepo(db *sql.DB) *SessionRepo {
+	return &SessionRepo{db: traced(db)}
+}
+
+const sessionColumns = `id, user_id, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at`
+
+func (r *SessionRepo) Create(ctx context.Context, s *domain.Session) error {
+	q := `INSERT INTO sessions (id, user_id, ip, user_agent, created_at, last_seen_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.ExecContext(ctx, q, s.ID, s.UserID, s.IP, s.UserAgent, s.CreatedAt, s.LastSeenAt, s.ExpiresAt)
+	return translateError(ctx, err)
+}
+
+func (r *SessionRepo) FindByID(ctx context.Context, id string) (*domain.Session, error) {
+	q := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ? LIMIT 1`
+	s, err := scanSession(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r *SessionRepo) FindActiveByUserID(ctx context.Context, userID int64, now time.Time) ([]*domain.Session, error) {
+	q := `SELECT ` + sessionColumns + ` FROM sessions WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY last_seen_at DESC`
+	rows, err := r.db.QueryContext(ctx, q, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*domain.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepo) Revoke(ctx context.Context, id string, userID int64, at time.Time) error {
+	q := `UPDATE sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, at, id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *SessionRepo) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	q := `UPDATE sessions SET last_seen_at = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, q, at, id)
+	return err
+}
+
+func scanSession(row rowScanner) (*domain.Session, error) {
+	var (
+		s         domain.Session
+		revokedAt sql.NullTime
+	)
+	if err := row.Scan(&s.ID, &s.UserID, &s.IP, &s.UserAgent, &s.CreatedAt,
+		&s.LastSeenAt, &s.ExpiresAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	s.RevokedAt = nullTimePtr(revokedAt)
+	return &s, nil
+}
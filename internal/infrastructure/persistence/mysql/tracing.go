@@ -0,0 +1,13 @@
+package mysql
+
+import (
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// dbtx is the part of *sql.DB the repositories use.
+type dbtx = persistence.DBTX
+
+func traced(db dbtx) dbtx {
+	return persistence.Traced(db, semconv.DBSystemMySQL)
+}
@@ -4,48 +4,189 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	domain "github.com/luthfiarsyad/mms/internal/domain/transaction"
 )
 
 type TxRepo struct {
-	db *sql.DB
+	router *Router
 }
 
 func NewTxRepo(db *sql.DB) *TxRepo {
-	return &TxRepo{db: db}
+	return &TxRepo{router: NewRouter(db, nil, 0)}
 }
 
+// NewRoutedTxRepo reads from the replicas of router and writes to its primary.
+func NewRoutedTxRepo(router *Router) *TxRepo {
+	return &TxRepo{router: router}
+}
+
+const txColumns = `id, user_id, amount, description, type, created_at, updated_at, deleted_at, version`
+
 func (r *TxRepo) Create(ctx context.Context, t *domain.Transaction) error {
 	q := `INSERT INTO transactions (user_id, amount, description, type, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
-	res, err := r.db.ExecContext(ctx, q, t.UserID, t.Amount, t.Description, t.Type, t.CreatedAt, t.UpdatedAt)
+	res, err := r.router.Writer(ctx).ExecContext(ctx, q, t.UserID, t.Amount, t.Description, t.Type, t.CreatedAt, t.UpdatedAt)
 	if err != nil {
-		return err
+		return translateError(ctx, err)
 	}
 	id, err := res.LastInsertId()
 	if err != nil {
 		return err
 	}
 	t.ID = id
+	t.Version = 1
+	return nil
+}
+
+// CreateMany relies on InnoDB reserving the IDs of a multi-row insert in
+// one go, which it does for inserts whose row count is known up front:
+// LastInsertId is the ID of the first row and every next row is
+// auto_increment_increment further on.
+func (r *TxRepo) CreateMany(ctx context.Context, ts []*domain.Transaction) error {
+	if len(ts) == 0 {
+		return nil
+	}
+	values := make([]string, len(ts))
+	args := make([]any, 0, 6*len(ts))
+	for i, t := range ts {
+		values[i] = "(?, ?, ?, ?, ?, ?)"
+		args = append(args, t.UserID, t.Amount, t.Description, t.Type, t.CreatedAt, t.UpdatedAt)
+	}
+	db := r.router.Writer(ctx)
+	var step int64
+	if err := db.QueryRowContext(ctx, `SELECT @@auto_increment_increment`).Scan(&step); err != nil {
+		return err
+	}
+	q := `INSERT INTO transactions (user_id, amount, description, type, created_at, updated_at) VALUES ` + strings.Join(values, ", ")
+	res, err := db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	first, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != int64(len(ts)) {
+		return fmt.Errorf("inserted %d of %d transactions", n, len(ts))
+	}
+	for i, t := range ts {
+		t.ID = first + int64(i)*step
+		t.Version = 1
+	}
 	return nil
 }
 
 func (r *TxRepo) FindByID(ctx context.Context, id int64) (*domain.Transaction, error) {
-	q := `SELECT id, user_id, amount, description, type, created_at, updated_at FROM transactions WHERE id = ? LIMIT 1`
-	row := r.db.QueryRowContext(ctx, q, id)
-	var t domain.Transaction
-	if err := row.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.Type, &t.CreatedAt, &t.UpdatedAt); err != nil {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE id = ? AND deleted_at IS NULL LIMIT 1`
+	t, err := scanTransaction(r.router.Reader(ctx).QueryRowContext(ctx, q, id))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, sql.ErrNoRows
 		}
 		return nil, err
 	}
-	return &t, nil
+	return t, nil
+}
+
+func (r *TxRepo) FindByUserID(ctx context.Context, userID int64, page domain.Page) ([]*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE user_id = ? AND deleted_at IS NULL ORDER BY created_at DESC, id DESC`
+	q, args := paged(q, []any{userID}, page)
+	return r.query(ctx, q, args...)
+}
+
+func (r *TxRepo) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	q := `SELECT COUNT(*) FROM transactions WHERE user_id = ? AND deleted_at IS NULL`
+	var n int
+	err := r.router.Reader(ctx).QueryRowContext(ctx, q, userID).Scan(&n)
+	return n, err
+}
+
+func (r *TxRepo) Update(ctx context.Context, t *domain.Transaction) error {
+	q := `UPDATE transactions SET amount = ?, description = ?, type = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ? AND deleted_at IS NULL`
+	res, err := r.router.Writer(ctx).ExecContext(ctx, q, t.Amount, t.Description, t.Type, t.UpdatedAt, t.ID, t.Version)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	t.Version++
+	return nil
 }
 
-func (r *TxRepo) FindByUserID(ctx context.Context, userID int64) ([]*domain.Transaction, error) {
-	q := `SELECT id, user_id, amount, description, type, created_at, updated_at FROM transactions WHERE user_id = ? ORDER BY created_at DESC`
-	rows, err := r.db.QueryContext(ctx, q, userID)
+func (r *TxRepo) Delete(ctx context.Context, id int64, at time.Time) error {
+	q := `UPDATE transactions SET deleted_at = ?, updated_at = updated_at WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.router.Writer(ctx).ExecContext(ctx, q, at, id)
+	return translateError(ctx, err)
+}
+
+func (r *TxRepo) FindDeletedByUserID(ctx context.Context, userID int64, page domain.Page) ([]*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE user_id = ? AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC`
+	q, args := paged(q, []any{userID}, page)
+	return r.query(ctx, q, args...)
+}
+
+func (r *TxRepo) CountDeletedByUserID(ctx context.Context, userID int64) (int, error) {
+	q := `SELECT COUNT(*) FROM transactions WHERE user_id = ? AND deleted_at IS NOT NULL`
+	var n int
+	err := r.router.Reader(ctx).QueryRowContext(ctx, q, userID).Scan(&n)
+	return n, err
+}
+
+func (r *TxRepo) FindDeletedByID(ctx context.Context, id, userID int64) (*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL`
+	return scanTransaction(r.router.Reader(ctx).QueryRowContext(ctx, q, id, userID))
+}
+
+func (r *TxRepo) Restore(ctx context.Context, id, userID int64) error {
+	q := `UPDATE transactions SET deleted_at = NULL, updated_at = updated_at WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL`
+	res, err := r.router.Writer(ctx).ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Purge locks the rows it is about to delete, as MySQL's DELETE cannot
+// return them.
+func (r *TxRepo) Purge(ctx context.Context, cutoff time.Time, limit int) ([]*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE deleted_at IS NOT NULL AND deleted_at < ? ORDER BY id LIMIT ? FOR UPDATE`
+	purged, err := r.query(ctx, q, cutoff, limit)
+	if err != nil || len(purged) == 0 {
+		return nil, err
+	}
+	marks := make([]string, len(purged))
+	ids := make([]any, len(purged))
+	for i, t := range purged {
+		marks[i], ids[i] = "?", t.ID
+	}
+	q = `DELETE FROM transactions WHERE id IN (` + strings.Join(marks, ", ") + `)`
+	if _, err := r.router.Writer(ctx).ExecContext(ctx, q, ids...); err != nil {
+		return nil, translateError(ctx, err)
+	}
+	return purged, nil
+}
+
+func (r *TxRepo) query(ctx context.Context, q string, args ...any) ([]*domain.Transaction, error) {
+	rows, err := r.router.Reader(ctx).QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -53,11 +194,11 @@ func (r *TxRepo) FindByUserID(ctx context.Context, userID int64) ([]*domain.Tran
 
 	var transactions []*domain.Transaction
 	for rows.Next() {
-		var t domain.Transaction
-		if err := rows.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.Type, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		t, err := scanTransaction(rows)
+		if err != nil {
 			return nil, err
 		}
-		transactions = append(transactions, &t)
+		transactions = append(transactions, t)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -67,14 +208,22 @@ func (r *TxRepo) FindByUserID(ctx context.Context, userID int64) ([]*domain.Tran
 	return transactions, nil
 }
 
-func (r *TxRepo) Update(ctx context.Context, t *domain.Transaction) error {
-	q := `UPDATE transactions SET amount = ?, description = ?, type = ?, updated_at = ? WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, q, t.Amount, t.Description, t.Type, t.UpdatedAt, t.ID)
-	return err
+// paged appends the LIMIT and OFFSET of page to q.
+func paged(q string, args []any, page domain.Page) (string, []any) {
+	if page.Limit <= 0 {
+		return q, args
+	}
+	return q + ` LIMIT ? OFFSET ?`, append(args, page.Limit, page.Offset)
 }
 
-func (r *TxRepo) Delete(ctx context.Context, id int64) error {
-	q := `DELETE FROM transactions WHERE id = ?`
-	_, err := r.db.ExecContext(ctx, q, id)
-	return err
-}
\ No newline at end of file
+func scanTransaction(row rowScanner) (*domain.Transaction, error) {
+	var (
+		t         domain.Transaction
+		deletedAt sql.NullTime
+	)
+	if err := row.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.Type, &t.CreatedAt, &t.UpdatedAt, &deletedAt, &t.Version); err != nil {
+		return nil, err
+	}
+	t.DeletedAt = nullTimePtr(deletedAt)
+	return &t, nil
+}
@@ -9,17 +9,20 @@ import (
 )
 
 type UserRepo struct {
-	db *sql.DB
+	db dbtx
 }
 
-func NewUserRepo(db *sql.DB) *UserRepo { return &UserRepo{db: db} }
+func NewUserRepo(db *sql.DB) *UserRepo { return &UserRepo{db: traced(db)} }
 func (r *UserRepo) Create(ctx context.Context, u *domain.User) error {
 	q := `INSERT INTO users (name, email, password, created_at) VALUES
 (?, ?, ?, ?)`
 	res, err := r.db.ExecContext(ctx, q, u.Name, u.Email, u.Password,
 		u.CreatedAt)
 	if err != nil {
-		return err
+		if isDuplicateEntry(err) {
+			return domain.ErrEmailTaken
+		}
+		return translateError(ctx, err)
 	}
 	id, err := res.LastInsertId()
 	if err != nil {
@@ -52,3 +55,8 @@ LIMIT 1`
 	}
 	return &u, nil
 }
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int64, password string) error {
+	q := `UPDATE users SET password = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, q, password, id)
+	return translateError(ctx, err)
+}
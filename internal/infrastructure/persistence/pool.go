@@ -0,0 +1,75 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/luthfiarsyad/mms/config"
+)
+
+// Defaults for zero values in config.DatabaseConfig.
+const (
+	DefaultMaxOpenConns      = 25
+	DefaultMaxIdleConns      = 10
+	DefaultConnMaxLifetime   = 5 * time.Minute
+	DefaultConnectTimeout    = 10 * time.Second
+	DefaultConnectMaxBackoff = 10 * time.Second
+
+	initialConnectBackoff = 500 * time.Millisecond
+)
+
+// ConfigurePool applies the pool settings of cfg to db.
+func ConfigurePool(db *sql.DB, cfg config.DatabaseConfig) {
+	maxOpen := cfg.MaxOpenConns
+	if maxOpen == 0 {
+		maxOpen = DefaultMaxOpenConns
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle == 0 {
+		maxIdle = DefaultMaxIdleConns
+	}
+	db.SetMaxOpenConns(maxOpen)
+	db.SetMaxIdleConns(maxIdle)
+	db.SetConnMaxLifetime(Seconds(cfg.ConnMaxLifetimeSeconds, DefaultConnMaxLifetime))
+	// Zero keeps idle connections until their lifetime ends.
+	db.SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeSeconds) * time.Second)
+}
+
+// PingWithRetry pings db until it answers, retrying up to cfg.ConnectRetries
+// times with exponential backoff, or until ctx is done.
+func PingWithRetry(ctx context.Context, db *sql.DB, cfg config.DatabaseConfig) error {
+	timeout := Seconds(cfg.ConnectTimeoutSeconds, DefaultConnectTimeout)
+	maxBackoff := Seconds(cfg.ConnectMaxBackoffSeconds, DefaultConnectMaxBackoff)
+	backoff := min(initialConnectBackoff, maxBackoff)
+
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := db.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt > cfg.ConnectRetries {
+			return fmt.Errorf("failed to connect DB after %d attempts: %w", attempt, err)
+		}
+
+		log.Printf("[DB] Connect attempt %d failed, retrying in %s: %v", attempt, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("failed to connect DB: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// Seconds converts a config value in seconds, using def for n <= 0.
+func Seconds(n int, def time.Duration) time.Duration {
+	if n <= 0 {
+		return def
+	}
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type AuditRepo struct {
+	db persistence.DBTX
+}
+
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: traced(db)}
+}
+
+const auditColumns = `id, actor_id, action, entity_type, entity_id, before_data, after_data, ip, request_id, created_at, prev_hash, hash`
+
+func (r *AuditRepo) Head(ctx context.Context) (string, error) {
+	q := `SELECT last_hash FROM audit_chain WHERE id = 1 FOR UPDATE`
+	var head string
+	if err := r.db.QueryRowContext(ctx, q).Scan(&head); err != nil {
+		return "", err
+	}
+	return head, nil
+}
+
+func (r *AuditRepo) Append(ctx context.Context, a *domain.Record) error {
+	q := `INSERT INTO audit_log (actor_id, action, entity_type, entity_id, before_data, after_data, ip, request_id, created_at, prev_hash, hash) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`
+	err := r.db.QueryRowContext(ctx, q, a.ActorID, a.Action, a.EntityType, a.EntityID, nullJSON(a.Before), nullJSON(a.After), a.IP, a.RequestID, a.CreatedAt, a.PrevHash, a.Hash).Scan(&a.ID)
+	if err != nil {
+		return TranslateError(ctx, err)
+	}
+
+	q = `UPDATE audit_chain SET last_hash = $1 WHERE id = 1`
+	_, err = r.db.ExecContext(ctx, q, a.Hash)
+	return TranslateError(ctx, err)
+}
+
+func (r *AuditRepo) Find(ctx context.Context, f domain.Filter) ([]*domain.Record, error) {
+	where, args := auditWhere(f)
+	q := `SELECT ` + auditColumns + ` FROM audit_log` + where + ` ORDER BY id DESC`
+	if f.Limit > 0 {
+		q += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+		args = append(args, f.Limit, f.Offset)
+	}
+	return r.query(ctx, q, args...)
+}
+
+func (r *AuditRepo) Count(ctx context.Context, f domain.Filter) (int, error) {
+	where, args := auditWhere(f)
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&n)
+	return n, err
+}
+
+// auditWhere returns the WHERE clause selecting the records matching f,
+// or "" when f matches everything.
+func auditWhere(f domain.Filter) (string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	cond := func(expr string, arg any) {
+		args = append(args, arg)
+		where = append(where, fmt.Sprintf(expr, len(args)))
+	}
+	if f.ActorID != 0 {
+		cond("actor_id = $%d", f.ActorID)
+	}
+	if f.EntityType != "" {
+		cond("entity_type = $%d", f.EntityType)
+	}
+	if f.EntityID != 0 {
+		cond("entity_id = $%d", f.EntityID)
+	}
+	if !f.From.IsZero() {
+		cond("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		cond("created_at < $%d", f.To)
+	}
+	if len(where) == 0 {
+		return "", nil
+	}
+	return ` WHERE ` + strings.Join(where, " AND "), args
+}
+
+func (r *AuditRepo) Chain(ctx context.Context, afterID int64, limit int) ([]*domain.Record, error) {
+	q := `SELECT ` + auditColumns + ` FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`
+	return r.query(ctx, q, afterID, limit)
+}
+
+func (r *AuditRepo) query(ctx context.Context, q string, args ...any) ([]*domain.Record, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*domain.Record
+	for rows.Next() {
+		a, err := scanAuditRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func scanAuditRecord(row rowScanner) (*domain.Record, error) {
+	var (
+		a             domain.Record
+		before, after sql.NullString
+	)
+	if err := row.Scan(&a.ID, &a.ActorID, &a.Action, &a.EntityType, &a.EntityID, &before, &after, &a.IP, &a.RequestID, &a.CreatedAt, &a.PrevHash, &a.Hash); err != nil {
+		return nil, err
+	}
+	a.Before = rawJSON(before)
+	a.After = rawJSON(after)
+	return &a, nil
+}
+
+// nullJSON stores an empty side of a diff as NULL.
+func nullJSON(raw json.RawMessage) any {
+	if raw == nil {
+		return nil
+	}
+	return string(raw)
+}
+
+func rawJSON(s sql.NullString) json.RawMessage {
+	if !s.Valid {
+		return nil
+	}
+	return json.RawMessage(s.String)
+}
@@ -0,0 +1,134 @@
+// Package postgres stores the repositories in PostgreSQL through the pgx
+// driver. It mirrors the MySQL backend with Postgres types: identity
+// columns read back with RETURNING, NUMERIC amounts and TIMESTAMPTZ times.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/migration"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// DefaultPort is the Postgres port; database.port defaults to MySQL's.
+const DefaultPort = 5432
+
+// sslModes maps config.DatabaseConfig.TLSMode to libpq's sslmode.
+var sslModes = map[string]string{
+	"":                "disable",
+	"disabled":        "disable",
+	"preferred":       "prefer",
+	"required":        "require",
+	"verify_ca":       "verify-ca",
+	"verify_identity": "verify-full",
+}
+
+// ConnString returns the connection string for cfg. A DSN is used as is;
+// otherwise a URL is assembled from the connection fields, the connect
+// timeout and the TLS settings.
+func ConnString(cfg config.DatabaseConfig) (string, error) {
+	if cfg.DSN != "" {
+		return cfg.DSN, nil
+	}
+
+	sslMode, ok := sslModes[cfg.TLSMode]
+	if !ok {
+		return "", fmt.Errorf("unknown database TLS mode %q", cfg.TLSMode)
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	timeout := persistence.Seconds(cfg.ConnectTimeoutSeconds, persistence.DefaultConnectTimeout)
+
+	q := url.Values{}
+	q.Set("sslmode", sslMode)
+	q.Set("connect_timeout", strconv.Itoa(int(timeout.Seconds())))
+	if cfg.TLSCAFile != "" {
+		q.Set("sslrootcert", cfg.TLSCAFile)
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(port)),
+		Path:     "/" + cfg.Name,
+		RawQuery: q.Encode(),
+	}
+	return u.String(), nil
+}
+
+// Connect opens the database described by cfg, retrying the first ping like
+// the MySQL backend, and applies the migrations.
+func Connect(ctx context.Context, cfg config.DatabaseConfig) (*sql.DB, error) {
+	dsn, err := ConnString(cfg)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB: %w", err)
+	}
+	persistence.ConfigurePool(db, cfg)
+
+	if err := persistence.PingWithRetry(ctx, db, cfg); err != nil {
+		db.Close()
+		return nil, err
+	}
+	log.Println("[DB] Connected to PostgreSQL successfully")
+
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return db, nil
+}
+
+// Migrate applies the embedded Postgres migrations that are newer than the
+// version recorded in schema_migrations.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	log.Println("[DB] Running migrations...")
+	if err := migration.Apply(ctx, db, "postgres"); err != nil {
+		return err
+	}
+	log.Println("[DB] Migrations completed successfully")
+	return nil
+}
+
+// SchemaVersion returns the highest applied migration version.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	return migration.Version(ctx, db)
+}
+
+// ExpectedSchemaVersion is the version of the newest embedded migration.
+func ExpectedSchemaVersion() (int, error) {
+	migrations, err := migration.Load("postgres")
+	if err != nil {
+		return 0, err
+	}
+	return migration.Latest(migrations), nil
+}
+
+func traced(db *sql.DB) persistence.DBTX {
+	return persistence.Traced(db, semconv.DBSystemPostgreSQL)
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
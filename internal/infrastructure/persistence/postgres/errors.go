@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/rs/zerolog"
+)
+
+// PostgreSQL SQLSTATE codes we translate.
+const (
+	errUniqueViolation     = "23505"
+	errForeignKeyViolation = "23503"
+)
+
+// TranslateError turns constraint violations into the same AppErrors as the
+// MySQL backend; the raw message is logged to the request logger instead.
+// Other errors, including sql.ErrNoRows, are returned unchanged.
+func TranslateError(ctx context.Context, err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	zerolog.Ctx(ctx).Debug().
+		Err(err).
+		Str("sqlstate", pgErr.Code).
+		Msg("PostgreSQL: statement failed")
+	switch pgErr.Code {
+	case errUniqueViolation:
+		return persistence.ErrDuplicateEntry.Wrap(err)
+	case errForeignKeyViolation:
+		// The same code covers both sides of the key; the message tells a
+		// blocked delete from an insert with a missing parent.
+		if strings.HasPrefix(pgErr.Message, "update or delete") {
+			return persistence.ErrStillReferenced.Wrap(err)
+		}
+		return persistence.ErrReferenceMissing.Wrap(err)
+	}
+	return err
+}
+
+func isDuplicateEntry(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == errUniqueViolation
+}
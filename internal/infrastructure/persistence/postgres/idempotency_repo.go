@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type IdempotencyRepo struct {
+	db persistence.DBTX
+}
+
+func NewIdempotencyRepo(db *sql.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{db: traced(db)}
+}
+
+func (r *IdempotencyRepo) Create(ctx context.Context, k *domain.Record) error {
+	q := `INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := r.db.ExecContext(ctx, q, k.UserID, k.Key, k.RequestHash, k.CreatedAt, k.ExpiresAt)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return domain.ErrKeyExists
+		}
+		return TranslateError(ctx, err)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) Find(ctx context.Context, userID int64, key string) (*domain.Record, error) {
+	q := `SELECT user_id, idempotency_key, request_hash, status, response_headers, response_body, created_at, expires_at FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+	var (
+		k      domain.Record
+		header sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, q, userID, key).Scan(&k.UserID, &k.Key, &k.RequestHash, &k.Status, &header, &k.Body, &k.CreatedAt, &k.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	if header.Valid {
+		if err := json.Unmarshal([]byte(header.String), &k.Header); err != nil {
+			return nil, err
+		}
+	}
+	return &k, nil
+}
+
+func (r *IdempotencyRepo) Complete(ctx context.Context, k *domain.Record) error {
+	header, err := json.Marshal(k.Header)
+	if err != nil {
+		return err
+	}
+	q := `UPDATE idempotency_keys SET status = $1, response_headers = $2, response_body = $3 WHERE user_id = $4 AND idempotency_key = $5`
+	_, err = r.db.ExecContext(ctx, q, k.Status, string(header), k.Body, k.UserID, k.Key)
+	return TranslateError(ctx, err)
+}
+
+func (r *IdempotencyRepo) Delete(ctx context.Context, userID int64, key string) error {
+	q := `DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2`
+	_, err := r.db.ExecContext(ctx, q, userID, key)
+	return TranslateError(ctx, err)
+}
+
+func (r *IdempotencyRepo) DeleteAbandoned(ctx context.Context, userID int64, key string, startedBefore time.Time) error {
+	q := `DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status = 0 AND created_at < $3`
+	_, err := r.db.ExecContext(ctx, q, userID, key, startedBefore)
+	return TranslateError(ctx, err)
+}
+
+func (r *IdempotencyRepo) Purge(ctx context.Context, cutoff time.Time) (int64, error) {
+	q := `DELETE FROM idempotency_keys WHERE expires_at < $1`
+	res, err := r.db.ExecContext(ctx, q, cutoff)
+	if err != nil {
+		return 0, TranslateError(ctx, err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,224 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type TxRepo struct {
+	db persistence.DBTX
+}
+
+func NewTxRepo(db *sql.DB) *TxRepo {
+	return &TxRepo{db: traced(db)}
+}
+
+const txColumns = `id, user_id, amount, description, type, created_at, updated_at, deleted_at, version`
+
+func (r *TxRepo) Create(ctx context.Context, t *domain.Transaction) error {
+	q := `INSERT INTO transactions (user_id, amount, description, type, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := r.db.QueryRowContext(ctx, q, t.UserID, t.Amount, t.Description, t.Type, t.CreatedAt, t.UpdatedAt).Scan(&t.ID)
+	if err != nil {
+		return TranslateError(ctx, err)
+	}
+	t.Version = 1
+	return nil
+}
+
+// CreateMany takes the IDs from the identity sequence first and inserts
+// them with the rows, as RETURNING does not promise to follow the order of
+// the VALUES list.
+func (r *TxRepo) CreateMany(ctx context.Context, ts []*domain.Transaction) error {
+	if len(ts) == 0 {
+		return nil
+	}
+	rows, err := r.db.QueryContext(ctx, `SELECT nextval(pg_get_serial_sequence('transactions', 'id')) FROM generate_series(1, $1)`, len(ts))
+	if err != nil {
+		return TranslateError(ctx, err)
+	}
+	defer rows.Close()
+	ids := make([]int64, 0, len(ts))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return TranslateError(ctx, err)
+	}
+	if len(ids) != len(ts) {
+		return fmt.Errorf("reserved %d of %d transaction IDs", len(ids), len(ts))
+	}
+
+	values := make([]string, len(ts))
+	args := make([]any, 0, 7*len(ts))
+	for i, t := range ts {
+		n := 7 * i
+		values[i] = fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+		args = append(args, ids[i], t.UserID, t.Amount, t.Description, t.Type, t.CreatedAt, t.UpdatedAt)
+	}
+	q := `INSERT INTO transactions (id, user_id, amount, description, type, created_at, updated_at) VALUES ` + strings.Join(values, ", ")
+	res, err := r.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return TranslateError(ctx, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != int64(len(ts)) {
+		return fmt.Errorf("inserted %d of %d transactions", n, len(ts))
+	}
+	for i, t := range ts {
+		t.ID = ids[i]
+		t.Version = 1
+	}
+	return nil
+}
+
+func (r *TxRepo) FindByID(ctx context.Context, id int64) (*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE id = $1 AND deleted_at IS NULL LIMIT 1`
+	t, err := scanTransaction(r.db.QueryRowContext(ctx, q, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func (r *TxRepo) FindByUserID(ctx context.Context, userID int64, page domain.Page) ([]*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE user_id = $1 AND deleted_at IS NULL ORDER BY created_at DESC, id DESC`
+	q, args := paged(q, []any{userID}, page)
+	return r.query(ctx, q, args...)
+}
+
+func (r *TxRepo) CountByUserID(ctx context.Context, userID int64) (int, error) {
+	q := `SELECT COUNT(*) FROM transactions WHERE user_id = $1 AND deleted_at IS NULL`
+	var n int
+	err := r.db.QueryRowContext(ctx, q, userID).Scan(&n)
+	return n, err
+}
+
+func (r *TxRepo) Update(ctx context.Context, t *domain.Transaction) error {
+	q := `UPDATE transactions SET amount = $1, description = $2, type = $3, updated_at = $4, version = version + 1 WHERE id = $5 AND version = $6 AND deleted_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, t.Amount, t.Description, t.Type, t.UpdatedAt, t.ID, t.Version)
+	if err != nil {
+		return TranslateError(ctx, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	t.Version++
+	return nil
+}
+
+func (r *TxRepo) Delete(ctx context.Context, id int64, at time.Time) error {
+	q := `UPDATE transactions SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, q, at, id)
+	return TranslateError(ctx, err)
+}
+
+func (r *TxRepo) FindDeletedByUserID(ctx context.Context, userID int64, page domain.Page) ([]*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE user_id = $1 AND deleted_at IS NOT NULL ORDER BY deleted_at DESC, id DESC`
+	q, args := paged(q, []any{userID}, page)
+	return r.query(ctx, q, args...)
+}
+
+func (r *TxRepo) CountDeletedByUserID(ctx context.Context, userID int64) (int, error) {
+	q := `SELECT COUNT(*) FROM transactions WHERE user_id = $1 AND deleted_at IS NOT NULL`
+	var n int
+	err := r.db.QueryRowContext(ctx, q, userID).Scan(&n)
+	return n, err
+}
+
+func (r *TxRepo) FindDeletedByID(ctx context.Context, id, userID int64) (*domain.Transaction, error) {
+	q := `SELECT ` + txColumns + ` FROM transactions WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`
+	return scanTransaction(r.db.QueryRowContext(ctx, q, id, userID))
+}
+
+func (r *TxRepo) Restore(ctx context.Context, id, userID int64) error {
+	q := `UPDATE transactions SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL`
+	res, err := r.db.ExecContext(ctx, q, id, userID)
+	if err != nil {
+		return TranslateError(ctx, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *TxRepo) Purge(ctx context.Context, cutoff time.Time, limit int) ([]*domain.Transaction, error) {
+	q := `DELETE FROM transactions WHERE id IN (
+		SELECT id FROM transactions WHERE deleted_at IS NOT NULL AND deleted_at < $1 ORDER BY id LIMIT $2 FOR UPDATE
+	) RETURNING ` + txColumns
+	purged, err := r.query(ctx, q, cutoff, limit)
+	if err != nil {
+		return nil, TranslateError(ctx, err)
+	}
+	return purged, nil
+}
+
+func (r *TxRepo) query(ctx context.Context, q string, args ...any) ([]*domain.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []*domain.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+// paged appends the LIMIT and OFFSET of page to q, numbering their
+// placeholders after args.
+func paged(q string, args []any, page domain.Page) (string, []any) {
+	if page.Limit <= 0 {
+		return q, args
+	}
+	q += fmt.Sprintf(` LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
+	return q, append(args, page.Limit, page.Offset)
+}
+
+func scanTransaction(row rowScanner) (*domain.Transaction, error) {
+	var (
+		t         domain.Transaction
+		deletedAt sql.NullTime
+	)
+	if err := row.Scan(&t.ID, &t.UserID, &t.Amount, &t.Description, &t.Type, &t.CreatedAt, &t.UpdatedAt, &deletedAt, &t.Version); err != nil {
+		return nil, err
+	}
+	t.DeletedAt = nullTimePtr(deletedAt)
+	return &t, nil
+}
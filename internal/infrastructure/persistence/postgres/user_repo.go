@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type UserRepo struct {
+	db persistence.DBTX
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{db: traced(db)}
+}
+
+func (r *UserRepo) Create(ctx context.Context, u *domain.User) error {
+	q := `INSERT INTO users (name, email, password, created_at) VALUES ($1, $2, $3, $4) RETURNING id`
+	err := r.db.QueryRowContext(ctx, q, u.Name, u.Email, u.Password, u.CreatedAt).Scan(&u.ID)
+	if err != nil {
+		if isDuplicateEntry(err) {
+			return domain.ErrEmailTaken
+		}
+		return TranslateError(ctx, err)
+	}
+	return nil
+}
+
+func (r *UserRepo) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
+	q := `SELECT id, name, email, password, created_at FROM users WHERE lower(email) = lower($1) LIMIT 1`
+	row := r.db.QueryRowContext(ctx, q, email)
+	var u domain.User
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) FindByID(ctx context.Context, id int64) (*domain.User, error) {
+	q := `SELECT id, name, email, password, created_at FROM users WHERE id = $1 LIMIT 1`
+	row := r.db.QueryRowContext(ctx, q, id)
+	var u domain.User
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *UserRepo) UpdatePassword(ctx context.Context, id int64, password string) error {
+	q := `UPDATE users SET password = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, q, password, id)
+	return TranslateError(ctx, err)
+}
@@ -0,0 +1,113 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	domain "github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+)
+
+type APIKeyRepo struct {
+	db persistence.DBTX
+}
+
+func NewAPIKeyRepo(db *sql.DB) *APIKeyRepo {
+	return &APIKeyRepo{db: traced(db)}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+func (r *APIKeyRepo) Create(ctx context.Context, k *domain.APIKey) error {
+	q := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := r.db.ExecContext(ctx, q, k.UserID, k.Name, k.Prefix, k.KeyHash, strings.Join(k.Scopes, ","), utcPtr(k.ExpiresAt), utc(k.CreatedAt))
+	if err != nil {
+		return translateError(ctx, err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	k.ID = id
+	return nil
+}
+
+func (r *APIKeyRepo) FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	q := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = ? LIMIT 1`
+	k, err := scanAPIKey(r.db.QueryRowContext(ctx, q, prefix))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, err
+	}
+	return k, nil
+}
+
+func (r *APIKeyRepo) FindByUserID(ctx context.Context, userID int64) ([]*domain.APIKey, error) {
+	q := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*domain.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id, userID int64, at time.Time) error {
+	q := `UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, utc(at), id, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	q := `UPDATE api_keys SET last_used_at = ? WHERE id = ?`
+	_, err := r.db.ExecContext(ctx, q, utc(at), id)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	var (
+		k                              domain.APIKey
+		scopes                         string
+		expiresAt, lastUsed, revokedAt sql.NullTime
+	)
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &scopes,
+		&expiresAt, &lastUsed, &revokedAt, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, ",")
+	}
+	k.ExpiresAt = nullTimePtr(expiresAt)
+	k.LastUsedAt = nullTimePtr(lastUsed)
+	k.RevokedAt = nullTimePtr(revokedAt)
+	return &k, nil
+}
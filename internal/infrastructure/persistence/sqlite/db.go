@@ -0,0 +1,114 @@
+// Package sqlite stores the repositories in a single SQLite file, for
+// self-hosting and development without a MySQL server. It uses the pure-Go
+// modernc.org/sqlite driver, so no cgo is needed.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/migration"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	_ "modernc.org/sqlite"
+)
+
+// DefaultPath is used when database.path is empty.
+const DefaultPath = "mms.db"
+
+const busyTimeout = 5 * time.Second
+
+// DSN returns the driver DSN for the database file at path. Foreign keys are
+// enforced like in MySQL, WAL lets readers run while a write is in progress,
+// and transactions take the write lock up front so concurrent writers wait
+// for busyTimeout instead of failing with SQLITE_BUSY.
+func DSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_time_format", "sqlite")
+	q.Set("_txlock", "immediate")
+	return path + "?" + q.Encode()
+}
+
+// Open opens the database file at path, creating it if needed, and applies
+// the migrations.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	if path == "" {
+		path = DefaultPath
+	}
+	db, err := sql.Open("sqlite", DSN(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DB: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open DB %s: %w", path, err)
+	}
+	log.Printf("[DB] Opened SQLite database %s", path)
+
+	if err := Migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return db, nil
+}
+
+// Migrate applies the embedded SQLite migrations that are newer than the
+// version recorded in schema_migrations.
+func Migrate(ctx context.Context, db *sql.DB) error {
+	log.Println("[DB] Running migrations...")
+	if err := migration.Apply(ctx, db, "sqlite"); err != nil {
+		return err
+	}
+	log.Println("[DB] Migrations completed successfully")
+	return nil
+}
+
+// SchemaVersion returns the highest applied migration version.
+func SchemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	return migration.Version(ctx, db)
+}
+
+// ExpectedSchemaVersion is the version of the newest embedded migration.
+func ExpectedSchemaVersion() (int, error) {
+	migrations, err := migration.Load("sqlite")
+	if err != nil {
+		return 0, err
+	}
+	return migration.Latest(migrations), nil
+}
+
+func traced(db *sql.DB) persistence.DBTX {
+	return persistence.Traced(db, semconv.DBSystemSqlite)
+}
+
+// utc normalizes a time before it is stored. SQLite keeps times as text and
+// compares them as strings, which only orders correctly in one time zone.
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
@@ -0,0 +1,40 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/rs/zerolog"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// translateError turns constraint violations into the same AppErrors as the
+// MySQL backend; the raw message is logged to the request logger instead.
+// Other errors, including sql.ErrNoRows, are returned unchanged.
+func translateError(ctx context.Context, err error) error {
+	var liteErr *sqlite.Error
+	if !errors.As(err, &liteErr) {
+		return err
+	}
+	zerolog.Ctx(ctx).Debug().
+		Err(err).
+		Int("sqlite_error", liteErr.Code()).
+		Msg("SQLite: statement failed")
+	switch liteErr.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return persistence.ErrDuplicateEntry.Wrap(err)
+	case sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY:
+		// SQLite does not say which side of the key failed. Deleting a
+		// referenced row cascades, so a violation is a missing parent.
+		return persistence.ErrReferenceMissing.Wrap(err)
+	}
+	return err
+}
+
+func isDuplicateEntry(err error) bool {
+	var liteErr *sqlite.Error
+	return errors.As(err, &liteErr) &&
+		(liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
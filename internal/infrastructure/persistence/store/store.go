@@ -0,0 +1,182 @@
+// Package store opens the storage backend selected by database.driver and
+// hands out its repositories behind the domain interfaces.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/memory"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/mysql"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/postgres"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/sqlite"
+)
+
+// Drivers accepted in database.driver; empty means DriverMySQL.
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	DriverMemory   = "memory"
+)
+
+// Store is one opened backend.
+type Store struct {
+	Driver string
+	// DB backs the health checks and pool metrics. It is nil for
+	// DriverMemory.
+	DB *sql.DB
+
+	Users        user.Repository
+	Transactions transaction.Repository
+	APIKeys      apikey.Repository
+	Sessions     session.Repository
+	Audit        audit.Repository
+	Idempotency  idempotency.Repository
+	// Transactor spans the repositories above.
+	Transactor audit.Transactor
+
+	schemaVersion         func(context.Context, *sql.DB) (int, error)
+	expectedSchemaVersion func() (int, error)
+	replicas              *mysql.Router
+	close                 func() error
+}
+
+// Open connects to the backend selected by cfg.Driver and applies its
+// migrations.
+func Open(ctx context.Context, cfg config.DatabaseConfig) (*Store, error) {
+	var s *Store
+	switch cfg.Driver {
+	case "", DriverMySQL:
+		db, router, err := mysql.Connect(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		s = NewMySQL(db, router)
+		s.close = router.Close
+	case DriverPostgres:
+		db, err := postgres.Connect(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		s = NewPostgres(db)
+	case DriverSQLite:
+		db, err := sqlite.Open(ctx, cfg.Path)
+		if err != nil {
+			return nil, err
+		}
+		s = NewSQLite(db)
+	case DriverMemory:
+		s = NewMemory()
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.Driver)
+	}
+	return s, nil
+}
+
+// NewMySQL uses db for everything but transaction reads, which router may
+// send to a replica.
+func NewMySQL(db *sql.DB, router *mysql.Router) *Store {
+	return &Store{
+		Driver:                DriverMySQL,
+		DB:                    db,
+		Users:                 mysql.NewUserRepo(db),
+		Transactions:          mysql.NewRoutedTxRepo(router),
+		APIKeys:               mysql.NewAPIKeyRepo(db),
+		Sessions:              mysql.NewSessionRepo(db),
+		Audit:                 mysql.NewAuditRepo(db),
+		Idempotency:           mysql.NewIdempotencyRepo(db),
+		Transactor:            persistence.NewTransactor(db),
+		schemaVersion:         mysql.SchemaVersion,
+		expectedSchemaVersion: mysql.ExpectedSchemaVersion,
+		replicas:              router,
+		close:                 db.Close,
+	}
+}
+
+// NewPostgres uses the PostgreSQL database db.
+func NewPostgres(db *sql.DB) *Store {
+	return &Store{
+		Driver:                DriverPostgres,
+		DB:                    db,
+		Users:                 postgres.NewUserRepo(db),
+		Transactions:          postgres.NewTxRepo(db),
+		APIKeys:               postgres.NewAPIKeyRepo(db),
+		Sessions:              postgres.NewSessionRepo(db),
+		Audit:                 postgres.NewAuditRepo(db),
+		Idempotency:           postgres.NewIdempotencyRepo(db),
+		Transactor:            persistence.NewTransactor(db),
+		schemaVersion:         postgres.SchemaVersion,
+		expectedSchemaVersion: postgres.ExpectedSchemaVersion,
+		close:                 db.Close,
+	}
+}
+
+// NewSQLite uses the SQLite database db.
+func NewSQLite(db *sql.DB) *Store {
+	return &Store{
+		Driver:                DriverSQLite,
+		DB:                    db,
+		Users:                 sqlite.NewUserRepo(db),
+		Transactions:          sqlite.NewTxRepo(db),
+		APIKeys:               sqlite.NewAPIKeyRepo(db),
+		Sessions:              sqlite.NewSessionRepo(db),
+		Audit:                 sqlite.NewAuditRepo(db),
+		Idempotency:           sqlite.NewIdempotencyRepo(db),
+		Transactor:            persistence.NewTransactor(db),
+		schemaVersion:         sqlite.SchemaVersion,
+		expectedSchemaVersion: sqlite.ExpectedSchemaVersion,
+		close:                 db.Close,
+	}
+}
+
+// NewMemory keeps everything in process memory; it has no DB and no schema.
+func NewMemory() *Store {
+	db := memory.New()
+	return &Store{
+		Driver:                DriverMemory,
+		Users:                 memory.NewUserRepo(db),
+		Transactions:          memory.NewTxRepo(db),
+		APIKeys:               memory.NewAPIKeyRepo(db),
+		Sessions:              memory.NewSessionRepo(db),
+		Audit:                 memory.NewAuditRepo(db),
+		Idempotency:           memory.NewIdempotencyRepo(db),
+		Transactor:            memory.NewTransactor(db),
+		schemaVersion:         func(context.Context, *sql.DB) (int, error) { return 0, nil },
+		expectedSchemaVersion: func() (int, error) { return 0, nil },
+		close:                 func() error { return nil },
+	}
+}
+
+// SchemaVersion returns the highest applied migration version.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	return s.schemaVersion(ctx, s.DB)
+}
+
+// ExpectedSchemaVersion is the version of the backend's newest migration.
+func (s *Store) ExpectedSchemaVersion() (int, error) {
+	return s.expectedSchemaVersion()
+}
+
+// Run checks the MySQL read replicas every interval until ctx is done. It
+// returns at once for backends without replicas.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	if s.replicas != nil {
+		s.replicas.Run(ctx, interval)
+	}
+}
+
+// Close closes the backend's connections.
+func (s *Store) Close() error {
+	return s.close()
+}
@@ -0,0 +1,100 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DBTX is the part of *sql.DB the repositories use.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// tracedDB opens a client span around every statement. The span carries the
+// sanitized SQL text, never the arguments. Statements run in the transaction
+// a Transactor of the wrapped *sql.DB opened in their context, if any.
+type tracedDB struct {
+	db     DBTX
+	system attribute.KeyValue
+}
+
+// Traced wraps db so every statement is traced; system is the db.system
+// attribute of the spans, e.g. semconv.DBSystemMySQL.
+func Traced(db DBTX, system attribute.KeyValue) DBTX {
+	return &tracedDB{db: db, system: system}
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, span := t.startQuery(ctx, query)
+	res, err := t.conn(ctx).ExecContext(ctx, query, args...)
+	tracing.End(span, &err)
+	return res, err
+}
+
+// QueryContext's span covers running the query, not iterating the rows.
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	ctx, span := t.startQuery(ctx, query)
+	rows, err := t.conn(ctx).QueryContext(ctx, query, args...)
+	tracing.End(span, &err)
+	return rows, err
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	ctx, span := t.startQuery(ctx, query)
+	row := t.conn(ctx).QueryRowContext(ctx, query, args...)
+	err := row.Err()
+	tracing.End(span, &err)
+	return row
+}
+
+func (t *tracedDB) conn(ctx context.Context) DBTX {
+	if db, ok := t.db.(*sql.DB); ok {
+		if tx, ok := txFrom(ctx, db); ok {
+			return tx
+		}
+	}
+	return t.db
+}
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"`)
+	numberLiteral  = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+	statementTable = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE)\s+` + "`?" + `(\w+)`)
+)
+
+func (t *tracedDB) startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	stmt := sanitizeSQL(query)
+	op, _, _ := strings.Cut(stmt, " ")
+	op = strings.ToUpper(op)
+
+	name := op
+	if m := statementTable.FindStringSubmatch(stmt); m != nil {
+		name += " " + m[1]
+	}
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			t.system,
+			semconv.DBOperationName(op),
+			semconv.DBQueryText(stmt),
+		),
+	)
+}
+
+// sanitizeSQL collapses whitespace and replaces literals with "?", so values
+// written into a statement never end up in a trace.
+func sanitizeSQL(query string) string {
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = numberLiteral.ReplaceAllString(query, "?")
+	return strings.TrimSpace(whitespace.ReplaceAllString(query, " "))
+}
@@ -0,0 +1,63 @@
+package persistence
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// txKey stores the open transaction of a database in a context, so
+// repositories of several databases never pick up each other's.
+type txKey struct{ db *sql.DB }
+
+// Transactor runs functions in a transaction of one database. Repositories
+// built on the same *sql.DB through Traced run their statements in the
+// transaction of the context they are given, so they need no changes to
+// take part.
+type Transactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+// WithinTx runs fn in a transaction that is committed when fn returns nil
+// and rolled back when it fails or panics. Calls nested in fn join the outer
+// transaction.
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if InTx(ctx, t.db) {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{t.db}, tx)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// InTx reports whether ctx carries an open transaction of db.
+func InTx(ctx context.Context, db *sql.DB) bool {
+	_, ok := txFrom(ctx, db)
+	return ok
+}
+
+func txFrom(ctx context.Context, db *sql.DB) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{db}).(*sql.Tx)
+	return tx, ok
+}
@@ -16,34 +16,32 @@ type PasetoService struct {
 	key    []byte
 }
 
-func NewPasetoService() *PasetoService {
-	cfg := config.Get()
-	if cfg == nil {
-		panic("config is not loaded")
-	}
-	
+// NewPasetoService uses the base64 encoded key in cfg.SymmetricKey.
+func NewPasetoService(cfg config.PasetoConfig) (*PasetoService, error) {
 	// Decode the base64 key
-	key, err := base64.StdEncoding.DecodeString(cfg.Paseto.SymmetricKey)
+	key, err := base64.StdEncoding.DecodeString(cfg.SymmetricKey)
 	if err != nil {
-		panic(fmt.Sprintf("failed to decode PASETO key: %v", err))
+		return nil, fmt.Errorf("failed to decode PASETO key: %w", err)
 	}
-	
+
 	// Verify key length (PASETO V2 requires 32 bytes)
 	if len(key) != 32 {
-		panic(fmt.Sprintf("invalid PASETO key length: got %d bytes, expected 32 bytes", len(key)))
+		return nil, fmt.Errorf("invalid PASETO key length: got %d bytes, expected 32 bytes", len(key))
 	}
-	
-	return &PasetoService{paseto: paseto.NewV2(), key: key}
+
+	return &PasetoService{paseto: paseto.NewV2(), key: key}, nil
 }
 
-type tokenPayload struct {
-	ID  int64     `json:"id"`
-	Exp time.Time `json:"exp"`
+// TokenClaims is the payload carried inside a PASETO token.
+type TokenClaims struct {
+	UserID    int64     `json:"id"`
+	SessionID string    `json:"sid"`
+	Exp       time.Time `json:"exp"`
 }
 
-func (p *PasetoService) CreateToken(userID int64, exp time.Duration) (string,
+func (p *PasetoService) CreateToken(userID int64, sessionID string, exp time.Duration) (string,
 	error) {
-	pl := tokenPayload{ID: userID, Exp: time.Now().Add(exp)}
+	pl := TokenClaims{UserID: userID, SessionID: sessionID, Exp: time.Now().Add(exp)}
 	token, err := p.paseto.Encrypt(p.key, pl, nil)
 
 	if err != nil {
@@ -51,13 +49,13 @@ func (p *PasetoService) CreateToken(userID int64, exp time.Duration) (string,
 	}
 	return token, nil
 }
-func (p *PasetoService) VerifyToken(token string) (int64, error) {
-	var pl tokenPayload
+func (p *PasetoService) VerifyToken(token string) (*TokenClaims, error) {
+	var pl TokenClaims
 	if err := p.paseto.Decrypt(token, p.key, &pl, nil); err != nil {
-		return 0, err
+		return nil, err
 	}
 	if time.Now().After(pl.Exp) {
-		return 0, errors.New("token expired")
+		return nil, errors.New("token expired")
 	}
-	return pl.ID, nil
+	return &pl, nil
 }
@@ -0,0 +1,266 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/luthfiarsyad/mms/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("password does not match")
+	ErrUnsupportedHash  = errors.New("unsupported password hash format")
+)
+
+// PasswordHasher hashes and verifies user passwords.
+// Encoded hashes carry their own algorithm identifier, so a hasher can tell
+// whether a stored hash was produced with outdated settings.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify returns ErrPasswordMismatch when the password is wrong.
+	Verify(encoded, password string) error
+	// NeedsRehash reports whether encoded should be replaced by a fresh Hash.
+	NeedsRehash(encoded string) bool
+}
+
+// NewPasswordHasher builds the hasher selected by cfg.Algorithm.
+// The returned hasher still verifies hashes produced by the other
+// algorithms, and reports them as needing a rehash.
+func NewPasswordHasher(cfg config.PasswordConfig) (PasswordHasher, error) {
+	argon := NewArgon2idHasher(Argon2idParams{
+		Memory:      cfg.Argon2.MemoryKiB,
+		Iterations:  cfg.Argon2.Iterations,
+		Parallelism: cfg.Argon2.Parallelism,
+		SaltLength:  cfg.Argon2.SaltLength,
+		KeyLength:   cfg.Argon2.KeyLength,
+	})
+	bc := NewBcryptHasher(cfg.BcryptCost)
+
+	h := &passwordHasher{
+		byAlgorithm: map[string]PasswordHasher{
+			AlgorithmArgon2id: argon,
+			AlgorithmBcrypt:   bc,
+		},
+	}
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", AlgorithmArgon2id:
+		h.algorithm = AlgorithmArgon2id
+	case AlgorithmBcrypt:
+		h.algorithm = AlgorithmBcrypt
+	default:
+		return nil, fmt.Errorf("unknown password algorithm %q", cfg.Algorithm)
+	}
+	return h, nil
+}
+
+// passwordHasher hashes with the preferred algorithm and dispatches
+// verification on the algorithm identified in the encoded hash.
+type passwordHasher struct {
+	algorithm   string
+	byAlgorithm map[string]PasswordHasher
+}
+
+func (h *passwordHasher) Hash(password string) (string, error) {
+	return h.byAlgorithm[h.algorithm].Hash(password)
+}
+
+func (h *passwordHasher) Verify(encoded, password string) error {
+	inner, ok := h.byAlgorithm[identifyHash(encoded)]
+	if !ok {
+		return ErrUnsupportedHash
+	}
+	return inner.Verify(encoded, password)
+}
+
+func (h *passwordHasher) NeedsRehash(encoded string) bool {
+	if identifyHash(encoded) != h.algorithm {
+		return true
+	}
+	return h.byAlgorithm[h.algorithm].NeedsRehash(encoded)
+}
+
+// identifyHash returns the algorithm name of an encoded hash, or "" if unknown.
+func identifyHash(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return AlgorithmArgon2id
+	case strings.HasPrefix(encoded, "$2a$"),
+		strings.HasPrefix(encoded, "$2b$"),
+		strings.HasPrefix(encoded, "$2y$"):
+		return AlgorithmBcrypt
+	}
+	return ""
+}
+
+// Argon2idParams are the tunable argon2id parameters. Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follows the OWASP baseline recommendation.
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Limits on the parameters of a stored argon2id hash. Anything outside them
+// is not a hash this service produced and is rejected before argon2 runs.
+const (
+	maxArgon2Memory     = 4 << 20 // KiB, 4 GiB
+	maxArgon2Iterations = 100
+	minArgon2KeyLength  = 16
+)
+
+// Argon2idHasher produces PHC-formatted hashes:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher returns an argon2id hasher; zero fields fall back to
+// DefaultArgon2idParams.
+func NewArgon2idHasher(p Argon2idParams) *Argon2idHasher {
+	if p.Memory == 0 {
+		p.Memory = DefaultArgon2idParams.Memory
+	}
+	if p.Iterations == 0 {
+		p.Iterations = DefaultArgon2idParams.Iterations
+	}
+	if p.Parallelism == 0 {
+		p.Parallelism = DefaultArgon2idParams.Parallelism
+	}
+	if p.SaltLength == 0 {
+		p.SaltLength = DefaultArgon2idParams.SaltLength
+	}
+	if p.KeyLength == 0 {
+		p.KeyLength = DefaultArgon2idParams.KeyLength
+	}
+	return &Argon2idHasher{params: p}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	b64 := base64.RawStdEncoding
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Verify(encoded, password string) error {
+	p, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return err
+	}
+	other := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(encoded string) bool {
+	p, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return p != h.params
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var p Argon2idParams
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	// argon2.IDKey panics on zero iterations or parallelism.
+	if p.Iterations < 1 || p.Iterations > maxArgon2Iterations || p.Parallelism < 1 || p.Memory > maxArgon2Memory {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+
+	b64 := base64.RawStdEncoding
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) < minArgon2KeyLength {
+		return p, nil, nil, ErrUnsupportedHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
+
+// BcryptMaxPasswordBytes is the longest password bcrypt hashes; Hash fails
+// on longer ones.
+const BcryptMaxPasswordBytes = 72
+
+// BcryptHasher wraps golang.org/x/crypto/bcrypt.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher returns a bcrypt hasher; a zero cost means bcrypt.DefaultCost.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(encoded, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrPasswordMismatch
+	}
+	return err
+}
+
+func (h *BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
@@ -0,0 +1,328 @@
+package security
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/luthfiarsyad/mms/config"
+)
+
+// Policy violation codes, stable for API clients.
+const (
+	ViolationTooShort      = "too_short"
+	ViolationTooLong       = "too_long"
+	ViolationMissingUpper  = "missing_upper"
+	ViolationMissingLower  = "missing_lower"
+	ViolationMissingDigit  = "missing_digit"
+	ViolationMissingSymbol = "missing_symbol"
+	ViolationPersonalInfo  = "contains_personal_info"
+	ViolationBreached      = "breached"
+)
+
+const (
+	defaultPasswordMinLength = 8
+	defaultPasswordMaxLength = 128
+)
+
+// PolicyViolation describes one rule a password failed.
+type PolicyViolation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PolicyError lists every rule a password failed, not only the first one.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+	return "password does not meet policy: " + strings.Join(msgs, "; ")
+}
+
+// PasswordPolicy validates new passwords on registration, password changes
+// and resets.
+type PasswordPolicy struct {
+	cfg      config.PasswordPolicyConfig
+	maxBytes int // 0 when the hasher takes passwords of any size
+	breached *BreachedPasswordList
+}
+
+// NewPasswordPolicy builds a policy from cfg.Policy. Zero lengths fall back
+// to sensible defaults; with bcrypt, which only takes BcryptMaxPasswordBytes,
+// passwords are also capped at that many bytes. If cfg.Policy.BreachedListFile
+// is set the list is opened eagerly, so a missing or malformed file fails at
+// startup. Close releases it.
+func NewPasswordPolicy(cfg config.PasswordConfig) (*PasswordPolicy, error) {
+	p := &PasswordPolicy{cfg: cfg.Policy}
+	if strings.EqualFold(cfg.Algorithm, AlgorithmBcrypt) {
+		p.maxBytes = BcryptMaxPasswordBytes
+	}
+	if p.cfg.MinLength == 0 {
+		p.cfg.MinLength = defaultPasswordMinLength
+	}
+	if p.cfg.MaxLength == 0 {
+		p.cfg.MaxLength = defaultPasswordMaxLength
+		if p.maxBytes > 0 {
+			p.cfg.MaxLength = p.maxBytes
+		}
+	}
+	if p.maxBytes > 0 && p.cfg.MaxLength > p.maxBytes {
+		return nil, fmt.Errorf("password policy: max_length %d exceeds the %d bytes bcrypt takes", p.cfg.MaxLength, p.maxBytes)
+	}
+	if p.cfg.MinLength > p.cfg.MaxLength {
+		return nil, fmt.Errorf("password policy: min_length %d exceeds max_length %d", p.cfg.MinLength, p.cfg.MaxLength)
+	}
+
+	if path := p.cfg.BreachedListFile; path != "" {
+		list, err := OpenBreachedPasswordList(path)
+		if err != nil {
+			return nil, err
+		}
+		p.breached = list
+	}
+	return p, nil
+}
+
+// Close closes the breached password list, if any.
+func (p *PasswordPolicy) Close() error {
+	if p.breached == nil {
+		return nil
+	}
+	return p.breached.Close()
+}
+
+// Validate checks password against every rule and returns a *PolicyError
+// listing all violations. personal holds values the password must not
+// contain, such as the user's email and name.
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	var violations []PolicyViolation
+	add := func(code, format string, args ...any) {
+		violations = append(violations, PolicyViolation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.MinLength {
+		add(ViolationTooShort, "must be at least %d characters", p.cfg.MinLength)
+	}
+	if length > p.cfg.MaxLength {
+		add(ViolationTooLong, "must be at most %d characters", p.cfg.MaxLength)
+	} else if p.maxBytes > 0 && len(password) > p.maxBytes {
+		add(ViolationTooLong, "must be at most %d bytes", p.maxBytes)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !hasUpper {
+		add(ViolationMissingUpper, "must contain an uppercase letter")
+	}
+	if p.cfg.RequireLower && !hasLower {
+		add(ViolationMissingLower, "must contain a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !hasDigit {
+		add(ViolationMissingDigit, "must contain a digit")
+	}
+	if p.cfg.RequireSymbol && !hasSymbol {
+		add(ViolationMissingSymbol, "must contain a symbol")
+	}
+
+	if p.cfg.DisallowPersonalInfo && containsPersonalInfo(password, personal) {
+		add(ViolationPersonalInfo, "must not contain your name or email")
+	}
+
+	if p.breached != nil {
+		breached, err := p.breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(ViolationBreached, "appears in a list of breached passwords")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// containsPersonalInfo reports whether password contains any personal value,
+// the local part of an email, or a word of a name. Fragments shorter than
+// three characters are ignored to avoid false positives.
+func containsPersonalInfo(password string, personal []string) bool {
+	lower := strings.ToLower(password)
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		fragments := []string{value}
+		if local, _, ok := strings.Cut(value, "@"); ok {
+			fragments = append(fragments, local)
+		}
+		fragments = append(fragments, strings.Fields(value)...)
+
+		for _, f := range fragments {
+			if len(f) >= 3 && strings.Contains(lower, f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// BreachedPasswordList is a local file of SHA-1 digests of breached
+// passwords, sorted like the downloadable Have I Been Pwned list ordered by
+// hash. Lookups binary search the file, so it is never loaded into memory
+// and no network access is needed.
+type BreachedPasswordList struct {
+	file  *os.File
+	start int64 // offset of the first digest, after any leading comments
+	size  int64
+}
+
+// OpenBreachedPasswordList opens a file with one SHA-1 hex digest per line,
+// optionally followed by ":<count>", sorted by digest. Blank lines and lines
+// starting with '#' may only come before the first digest.
+func OpenBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	l := &BreachedPasswordList{file: f, size: info.Size()}
+
+	// Skip the leading comments and check the first digest, so a file in
+	// some other format is refused at startup rather than never matching.
+	for l.start < l.size {
+		line, next, err := l.lineAt(l.start)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("read breached password list: %w", err)
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			l.start = next
+			continue
+		}
+		if _, ok := lineDigest(line); !ok {
+			f.Close()
+			return nil, fmt.Errorf("breached password list %s: invalid SHA-1 digest %q", path, trimmed)
+		}
+		break
+	}
+	return l, nil
+}
+
+// Close closes the list file.
+func (l *BreachedPasswordList) Close() error {
+	return l.file.Close()
+}
+
+// Contains reports whether password's SHA-1 digest is in the list.
+func (l *BreachedPasswordList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	// A line holding digest, if any, starts in [lo, hi).
+	lo, hi := l.start, l.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start := l.lineStart(mid)
+		if start >= hi {
+			hi = mid
+			continue
+		}
+		line, next, err := l.lineAt(start)
+		if err != nil {
+			return false, fmt.Errorf("read breached password list: %w", err)
+		}
+		got, _ := lineDigest(line)
+		switch cmp := strings.Compare(got, digest); {
+		case cmp == 0:
+			return true, nil
+		case cmp < 0:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// maxBreachedLine bounds the lines of the list: a digest, a colon and a count.
+const maxBreachedLine = 128
+
+// lineStart returns the offset of the first line that starts at or after
+// off, or the size of the file if there is none.
+func (l *BreachedPasswordList) lineStart(off int64) int64 {
+	if off == l.start {
+		return off
+	}
+	buf := make([]byte, maxBreachedLine)
+	n, _ := l.file.ReadAt(buf, off-1)
+	if i := bytes.IndexByte(buf[:n], '\n'); i >= 0 {
+		return off + int64(i)
+	}
+	return l.size
+}
+
+// lineAt returns the line starting at off and the offset of the next one.
+// It returns io.EOF when off is at the end of the file.
+func (l *BreachedPasswordList) lineAt(off int64) (string, int64, error) {
+	if off >= l.size {
+		return "", off, io.EOF
+	}
+	buf := make([]byte, maxBreachedLine)
+	n, err := l.file.ReadAt(buf, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", off, err
+	}
+	buf = buf[:n]
+	if i := bytes.IndexByte(buf, '\n'); i >= 0 {
+		return string(buf[:i]), off + int64(i) + 1, nil
+	}
+	if off+int64(n) < l.size {
+		return "", off, fmt.Errorf("line at offset %d is longer than %d bytes", off, maxBreachedLine)
+	}
+	return string(buf), l.size, nil
+}
+
+// lineDigest returns the uppercased digest of a list line.
+func lineDigest(line string) (string, bool) {
+	digest, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	digest = strings.ToUpper(digest)
+	if len(digest) != sha1.Size*2 {
+		return digest, false
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return digest, false
+	}
+	return digest, true
+}
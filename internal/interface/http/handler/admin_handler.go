@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+	"github.com/rs/zerolog"
+)
+
+// AdminHandler serves operational endpoints. It is only mounted behind
+// basic auth; see config.AdminConfig.
+type AdminHandler struct {
+	audits *usecase.AuditUsecase
+	auth   *usecase.AuthUsecase
+}
+
+func NewAdminHandler(audits *usecase.AuditUsecase, auth *usecase.AuthUsecase) *AdminHandler {
+	return &AdminHandler{audits: audits, auth: auth}
+}
+
+type logLevelView struct {
+	Level string `json:"level"`
+}
+
+func (h *AdminHandler) GetLogLevel(c *gin.Context) {
+	response.OK(c, logLevelView{Level: logger.Level().String()})
+}
+
+// SetLogLevel changes the log level of the running process. The change is
+// not persisted and only affects this instance.
+func (h *AdminHandler) SetLogLevel(c *gin.Context) {
+	var req request.SetLogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	previous := logger.Level()
+	// The request only allows levels SetLevel knows, so a failure here is
+	// a bug rather than a bad request.
+	if err := logger.SetLevel(req.Level); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// Logged without a level so the change is visible whatever the new level is.
+	zerolog.Ctx(c.Request.Context()).Log().
+		Str("from", previous.String()).
+		Str("to", req.Level).
+		Msg("AdminHandler.SetLogLevel: log level changed")
+	response.OK(c, logLevelView{Level: logger.Level().String()})
+}
+
+type auditVerifyView struct {
+	Intact  bool   `json:"intact"`
+	Checked int    `json:"checked"`
+	Problem string `json:"problem,omitempty"`
+}
+
+// VerifyAudit checks the hash chain of the audit log. A broken chain is a
+// finding, not a failed request, so it is reported with 200.
+func (h *AdminHandler) VerifyAudit(c *gin.Context) {
+	checked, err := h.audits.VerifyChain(c.Request.Context())
+	if err != nil && !errors.Is(err, audit.ErrTampered) {
+		_ = c.Error(err)
+		return
+	}
+	view := auditVerifyView{Intact: err == nil, Checked: checked}
+	if err != nil {
+		view.Problem = err.Error()
+	}
+	response.OK(c, view)
+}
+
+// ResetPassword sets a new password for the user named by the :id param and
+// signs them out everywhere. The password policy applies as on registration.
+func (h *AdminHandler) ResetPassword(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		_ = c.Error(errInvalidID.WithMessage("invalid user id"))
+		return
+	}
+	var req request.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	if err := h.auth.ResetPassword(c.Request.Context(), id, req.NewPassword); err != nil {
+		_ = c.Error(passwordError(err))
+		return
+	}
+	response.NoContent(c)
+}
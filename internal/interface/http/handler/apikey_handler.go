@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+)
+
+type APIKeyHandler struct {
+	usecase *usecase.APIKeyUsecase
+}
+
+// NewAPIKeyHandler takes the usecase because AuthMiddleware shares it.
+func NewAPIKeyHandler(uc *usecase.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{usecase: uc}
+}
+
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req request.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	userID := c.GetInt64("user_id")
+	k, raw, err := h.usecase.CreateKey(c.Request.Context(), userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	// The raw key is returned only here; it is stored hashed.
+	response.Created(c, gin.H{"api_key": k, "key": raw})
+}
+
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	keys, err := h.usecase.ListKeys(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if keys == nil {
+		keys = []*apikey.APIKey{}
+	}
+	response.OK(c, keys)
+}
+
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID.WithMessage("invalid api key id"))
+		return
+	}
+	userID := c.GetInt64("user_id")
+	if err := h.usecase.RevokeKey(c.Request.Context(), userID, id); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response.NoContent(c)
+}
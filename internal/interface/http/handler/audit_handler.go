@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+const dateLayout = "2006-01-02"
+
+var errInvalidDate = apperr.BadRequest("INVALID_DATE", "dates must be RFC 3339 times or YYYY-MM-DD")
+
+type AuditHandler struct {
+	usecase *usecase.AuditUsecase
+}
+
+func NewAuditHandler(uc *usecase.AuditUsecase) *AuditHandler {
+	return &AuditHandler{usecase: uc}
+}
+
+// List returns one page of the changes the user made, newest first,
+// filtered by the entity, entity_id, from and to query parameters.
+func (h *AuditHandler) List(c *gin.Context) {
+	var q request.ListAuditQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	from, _, err := parseDate(q.From)
+	if err != nil {
+		_ = c.Error(errInvalidDate.WithDetails("from").Wrap(err))
+		return
+	}
+	to, dateOnly, err := parseDate(q.To)
+	if err != nil {
+		_ = c.Error(errInvalidDate.WithDetails("to").Wrap(err))
+		return
+	}
+	if dateOnly {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	meta := response.NewMeta(q.Page, q.PerPage, 0)
+	filter := audit.Filter{EntityType: q.Entity, EntityID: q.EntityID, From: from, To: to, Limit: meta.PerPage, Offset: meta.Offset()}
+	page, total, err := h.usecase.ListUserRecords(c.Request.Context(), c.GetInt64("user_id"), filter)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if page == nil {
+		page = []*audit.Record{}
+	}
+	meta = response.NewMeta(q.Page, q.PerPage, total)
+	response.Paginated(c, page, meta)
+}
+
+// parseDate accepts an RFC 3339 time or a date, which is midnight UTC;
+// dateOnly reports which it was. An empty s is the zero time.
+func parseDate(s string) (t time.Time, dateOnly bool, err error) {
+	if s == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err = time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	t, err = time.Parse(dateLayout, s)
+	return t, err == nil, err
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+var (
+	errInvalidID    = apperr.BadRequest("INVALID_ID", "invalid id")
+	errWeakPassword = apperr.BadRequest("WEAK_PASSWORD", "password does not meet policy")
+	errNoIfMatch    = apperr.New("PRECONDITION_REQUIRED", http.StatusPreconditionRequired, "If-Match header is required")
+)
+
+// Domain errors keep no HTTP knowledge; this is where they get their
+// client-facing code and status.
+func init() {
+	apperr.Register(user.ErrInvalidCreds, apperr.Unauthorized("INVALID_CREDENTIALS", "invalid credentials"))
+	apperr.Register(user.ErrUserNotFound, apperr.NotFound("USER_NOT_FOUND", "user not found"))
+	apperr.Register(user.ErrEmailTaken, apperr.Conflict("EMAIL_TAKEN", "email is already registered"))
+
+	apperr.Register(transaction.ErrTransactionNotFound, apperr.NotFound("TRANSACTION_NOT_FOUND", "transaction not found"))
+	apperr.Register(transaction.ErrInvalidAmount, apperr.BadRequest("INVALID_AMOUNT", "amount must be greater than 0"))
+	apperr.Register(transaction.ErrInvalidType, apperr.BadRequest("INVALID_TRANSACTION_TYPE", "transaction type must be 'income' or 'expense'"))
+	apperr.Register(transaction.ErrEmptyPatch, apperr.BadRequest("EMPTY_PATCH", "at least one field must be given"))
+	apperr.Register(transaction.ErrInvalidOperation, apperr.BadRequest("INVALID_OPERATION", "operation must be 'create', 'update' or 'delete'"))
+	apperr.Register(transaction.ErrBatchTooLarge, apperr.BadRequest("BATCH_TOO_LARGE", "a batch holds at most 100 operations"))
+	apperr.Register(transaction.ErrBatchAborted, apperr.New("BATCH_ABORTED", http.StatusUnprocessableEntity, "batch was rolled back because an operation failed"))
+	apperr.Register(transaction.ErrVersionConflict, apperr.New("VERSION_CONFLICT", http.StatusPreconditionFailed, "transaction was modified by another request"))
+
+	apperr.Register(apikey.ErrAPIKeyNotFound, apperr.NotFound("API_KEY_NOT_FOUND", "api key not found"))
+	apperr.Register(apikey.ErrInvalidAPIKey, apperr.Unauthorized("INVALID_API_KEY", "invalid api key"))
+	apperr.Register(apikey.ErrInvalidScope, apperr.BadRequest("INVALID_SCOPE", "unknown api key scope"))
+	apperr.Register(apikey.ErrInvalidName, apperr.BadRequest("INVALID_NAME", "api key name is required"))
+	apperr.Register(apikey.ErrInvalidExpiry, apperr.BadRequest("INVALID_EXPIRY", "api key expiry must be in the future"))
+
+	apperr.Register(idempotency.ErrInvalidKey, apperr.BadRequest("INVALID_IDEMPOTENCY_KEY", "idempotency key must be 1 to 255 characters"))
+	apperr.Register(idempotency.ErrKeyReused, apperr.New("IDEMPOTENCY_KEY_REUSED", http.StatusUnprocessableEntity, "idempotency key was already used for a different request"))
+	apperr.Register(idempotency.ErrInProgress, apperr.Conflict("IDEMPOTENCY_KEY_IN_USE", "a request with this idempotency key is still in progress"))
+
+	apperr.Register(session.ErrSessionNotFound, apperr.NotFound("SESSION_NOT_FOUND", "session not found"))
+	apperr.Register(session.ErrSessionInactive, apperr.Unauthorized("SESSION_INACTIVE", "session expired or revoked"))
+}
+
+// passwordError turns a password policy failure into a 400 listing every
+// violation; other errors pass through unchanged.
+func passwordError(err error) error {
+	var policyErr *security.PolicyError
+	if errors.As(err, &policyErr) {
+		return errWeakPassword.WithDetails(policyErr.Violations).Wrap(err)
+	}
+	return err
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/health"
+)
+
+// ShutdownState reports whether the process has started shutting down.
+type ShutdownState interface {
+	ShuttingDown() bool
+}
+
+type HealthHandler struct {
+	checks *health.Registry
+	state  ShutdownState
+}
+
+func NewHealthHandler(checks *health.Registry, state ShutdownState) *HealthHandler {
+	return &HealthHandler{checks: checks, state: state}
+}
+
+// Livez reports that the process is up. It never looks at dependencies, so
+// a database outage does not get the process restarted.
+func (h *HealthHandler) Livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Readyz reports whether the instance should receive traffic. It fails as
+// soon as shutdown begins or any registered check fails. With ?verbose the
+// result of every check is included.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	if h.state.ShuttingDown() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "shutting_down"})
+		return
+	}
+
+	report := h.checks.Run(c.Request.Context())
+	code := http.StatusOK
+	if !report.OK() {
+		code = http.StatusServiceUnavailable
+	}
+	if _, verbose := c.GetQuery("verbose"); verbose {
+		c.JSON(code, report)
+		return
+	}
+	c.JSON(code, gin.H{"status": report.Status})
+}
@@ -1 +0,0 @@
-package handler
\ No newline at end of file
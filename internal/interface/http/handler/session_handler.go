@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+)
+
+type SessionHandler struct {
+	usecase *usecase.SessionUsecase
+}
+
+// NewSessionHandler takes the usecase because AuthMiddleware shares its cache.
+func NewSessionHandler(uc *usecase.SessionUsecase) *SessionHandler {
+	return &SessionHandler{usecase: uc}
+}
+
+type sessionView struct {
+	*session.Session
+	Current bool `json:"current"`
+}
+
+func (h *SessionHandler) List(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	sessions, err := h.usecase.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	current := c.GetString("session_id")
+	views := make([]sessionView, len(sessions))
+	for i, s := range sessions {
+		views[i] = sessionView{Session: s, Current: s.ID == current}
+	}
+	response.OK(c, views)
+}
+
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	userID := c.GetInt64("user_id")
+	if err := h.usecase.RevokeSession(c.Request.Context(), userID, c.Param("id")); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response.NoContent(c)
+}
@@ -1 +1,277 @@
-package handler
\ No newline at end of file
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+type TransactionHandler struct {
+	usecase *usecase.TransactionUsecase
+}
+
+func NewTransactionHandler(uc *usecase.TransactionUsecase) *TransactionHandler {
+	return &TransactionHandler{usecase: uc}
+}
+
+func (h *TransactionHandler) Create(c *gin.Context) {
+	var req request.CreateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	userID := c.GetInt64("user_id")
+	t, err := h.usecase.CreateTransaction(c.Request.Context(), userID, req.Amount, req.Description, req.Type)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	setETag(c, t)
+	response.Created(c, t)
+}
+
+// List returns one page of the user's transactions, selected with the
+// page and per_page query parameters.
+func (h *TransactionHandler) List(c *gin.Context) {
+	var q request.ListTransactionsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	meta := response.NewMeta(q.Page, q.PerPage, 0)
+	page, total, err := h.usecase.GetUserTransactions(c.Request.Context(), c.GetInt64("user_id"), transaction.Page{Limit: meta.PerPage, Offset: meta.Offset()})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if page == nil {
+		page = []*transaction.Transaction{}
+	}
+	response.Paginated(c, page, response.NewMeta(q.Page, q.PerPage, total))
+}
+
+// Get returns the transaction with its version as ETag, which PUT and PATCH
+// need back in If-Match.
+func (h *TransactionHandler) Get(c *gin.Context) {
+	t, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	setETag(c, t)
+	response.OK(c, t)
+}
+
+// Update replaces the amount, description and type of the transaction. The
+// If-Match header must hold its current ETag.
+func (h *TransactionHandler) Update(c *gin.Context) {
+	existing, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	var req request.UpdateTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	h.update(c, existing, transaction.Patch{Amount: &req.Amount, Description: &req.Description, Type: &req.Type})
+}
+
+// Patch changes only the fields present in the body. Like Update, it needs
+// the current ETag in If-Match.
+func (h *TransactionHandler) Patch(c *gin.Context) {
+	existing, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	var req request.PatchTransactionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	p := transaction.Patch{Amount: req.Amount, Description: req.Description, Type: req.Type}
+	if p.Empty() {
+		_ = c.Error(transaction.ErrEmptyPatch)
+		return
+	}
+	h.update(c, existing, p)
+}
+
+func (h *TransactionHandler) update(c *gin.Context, existing *transaction.Transaction, p transaction.Patch) {
+	if err := checkIfMatch(c, existing); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	t, err := h.usecase.UpdateTransaction(c.Request.Context(), existing.ID, existing.Version, p)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	setETag(c, t)
+	response.OK(c, t)
+}
+
+func (h *TransactionHandler) Delete(c *gin.Context) {
+	existing, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	if err := h.usecase.DeleteTransaction(c.Request.Context(), existing.ID); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	response.NoContent(c)
+}
+
+// Trash returns one page of the user's deleted transactions, most recently
+// deleted first.
+func (h *TransactionHandler) Trash(c *gin.Context) {
+	var q request.ListTransactionsQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	meta := response.NewMeta(q.Page, q.PerPage, 0)
+	page, total, err := h.usecase.GetUserTrash(c.Request.Context(), c.GetInt64("user_id"), transaction.Page{Limit: meta.PerPage, Offset: meta.Offset()})
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	if page == nil {
+		page = []*transaction.Transaction{}
+	}
+	response.Paginated(c, page, response.NewMeta(q.Page, q.PerPage, total))
+}
+
+// Restore takes a transaction out of the user's trash. Transactions that are
+// not in it, or belong to someone else, are not found.
+func (h *TransactionHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID.WithMessage("invalid transaction id"))
+		return
+	}
+	t, err := h.usecase.RestoreTransaction(c.Request.Context(), id, c.GetInt64("user_id"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	setETag(c, t)
+	response.OK(c, t)
+}
+
+// batchResult is the outcome of one operation of a batch. Status is the
+// status the operation would have had as a request of its own.
+type batchResult struct {
+	Index  int                      `json:"index"`
+	Op     string                   `json:"op"`
+	ID     int64                    `json:"id,omitempty"`
+	Status int                      `json:"status"`
+	Data   *transaction.Transaction `json:"data,omitempty"`
+	Error  *response.ErrorBody      `json:"error,omitempty"`
+}
+
+// Batch runs up to 100 creates, updates and deletes on the user's
+// transactions and answers with one result per operation. Updates carry the
+// version they are based on instead of If-Match. An atomic batch that fails
+// is rolled back and answered with 422, the results in the error details.
+func (h *TransactionHandler) Batch(c *gin.Context) {
+	var req request.BatchTransactionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		_ = c.Error(response.BindError(err))
+		return
+	}
+	ops := make([]transaction.Operation, len(req.Operations))
+	for i, item := range req.Operations {
+		ops[i] = transaction.Operation{
+			Kind:    transaction.OpKind(item.Op),
+			ID:      item.ID,
+			Version: item.Version,
+			Fields:  transaction.Patch{Amount: item.Amount, Description: item.Description, Type: item.Type},
+		}
+	}
+	results, err := h.usecase.BatchTransactions(c.Request.Context(), c.GetInt64("user_id"), ops, req.Atomic)
+	if err != nil && !errors.Is(err, transaction.ErrBatchAborted) {
+		_ = c.Error(err)
+		return
+	}
+
+	items := make([]batchResult, len(results))
+	for i, r := range results {
+		item := batchResult{Index: i, Op: string(ops[i].Kind), ID: ops[i].ID, Data: r.Transaction}
+		switch {
+		case r.Err != nil:
+			appErr := apperr.From(r.Err)
+			item.Status = appErr.Status
+			item.Error = &response.ErrorBody{Code: appErr.Code, Message: appErr.Message}
+		case ops[i].Kind == transaction.OpCreate:
+			item.ID = r.Transaction.ID
+			item.Status = http.StatusCreated
+		case ops[i].Kind == transaction.OpDelete:
+			item.Status = http.StatusNoContent
+		default:
+			item.Status = http.StatusOK
+		}
+		items[i] = item
+	}
+	if err != nil {
+		_ = c.Error(apperr.From(err).WithDetails(items))
+		return
+	}
+	response.OK(c, items)
+}
+
+// loadOwned fetches the transaction named by the :id param and makes sure it
+// belongs to the authenticated user. Other users' transactions are reported
+// as not found so their existence is not leaked.
+func (h *TransactionHandler) loadOwned(c *gin.Context) (*transaction.Transaction, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidID.WithMessage("invalid transaction id"))
+		return nil, false
+	}
+	t, err := h.usecase.GetTransactionByID(c.Request.Context(), id)
+	if err != nil {
+		_ = c.Error(err)
+		return nil, false
+	}
+	if t.UserID != c.GetInt64("user_id") {
+		_ = c.Error(transaction.ErrTransactionNotFound)
+		return nil, false
+	}
+	return t, true
+}
+
+// etag is the strong entity tag of the transaction's version.
+func etag(t *transaction.Transaction) string {
+	return `"` + strconv.FormatInt(t.Version, 10) + `"`
+}
+
+func setETag(c *gin.Context, t *transaction.Transaction) {
+	c.Header("ETag", etag(t))
+}
+
+// checkIfMatch makes sure the If-Match header names the current version of t,
+// so an update is never based on a copy someone else has since changed. The
+// update itself is still made conditional on that version, which catches a
+// change that lands after this check.
+func checkIfMatch(c *gin.Context, t *transaction.Transaction) error {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return errNoIfMatch
+	}
+	current := etag(t)
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == current {
+			return nil
+		}
+	}
+	return transaction.ErrVersionConflict
+}
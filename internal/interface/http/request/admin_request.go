@@ -0,0 +1,5 @@
+package request
+
+type SetLogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=trace debug info warn error"`
+}
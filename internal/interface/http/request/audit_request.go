@@ -0,0 +1,12 @@
+package request
+
+// ListAuditQuery filters the audit log. From and To take an RFC 3339 time or
+// a date; a date in To includes the whole day.
+type ListAuditQuery struct {
+	Entity   string `form:"entity" binding:"omitempty,oneof=transaction user"`
+	EntityID int64  `form:"entity_id" binding:"omitempty,min=1"`
+	From     string `form:"from"`
+	To       string `form:"to"`
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PerPage  int    `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
@@ -1 +1,42 @@
-package request
\ No newline at end of file
+package request
+
+type CreateTransactionRequest struct {
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Description string  `json:"description" binding:"required,max=500"`
+	Type        string  `json:"type" binding:"required,oneof=income expense"`
+}
+
+type UpdateTransactionRequest struct {
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Description string  `json:"description" binding:"required,max=500"`
+	Type        string  `json:"type" binding:"required,oneof=income expense"`
+}
+
+// PatchTransactionRequest changes only the fields it contains.
+type PatchTransactionRequest struct {
+	Amount      *float64 `json:"amount" binding:"omitnil,gt=0"`
+	Description *string  `json:"description" binding:"omitnil,min=1,max=500"`
+	Type        *string  `json:"type" binding:"omitnil,oneof=income expense"`
+}
+
+// BatchTransactionsRequest holds up to 100 operations. Creates need amount,
+// description and type; updates need id, version and at least one field;
+// deletes need id.
+type BatchTransactionsRequest struct {
+	Atomic     bool                 `json:"atomic"`
+	Operations []BatchOperationItem `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+type BatchOperationItem struct {
+	Op          string   `json:"op" binding:"required,oneof=create update delete"`
+	ID          int64    `json:"id" binding:"required_unless=Op create,omitempty,gt=0"`
+	Version     int64    `json:"version" binding:"required_if=Op update,omitempty,gt=0"`
+	Amount      *float64 `json:"amount" binding:"required_if=Op create,omitnil,gt=0"`
+	Description *string  `json:"description" binding:"required_if=Op create,omitnil,min=1,max=500"`
+	Type        *string  `json:"type" binding:"required_if=Op create,omitnil,oneof=income expense"`
+}
+
+type ListTransactionsQuery struct {
+	Page    int `form:"page" binding:"omitempty,min=1"`
+	PerPage int `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
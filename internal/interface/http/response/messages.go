@@ -0,0 +1,92 @@
+package response
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// Supported locales. English is the fallback.
+const (
+	LocaleEnglish    = "en"
+	LocaleIndonesian = "id"
+)
+
+const keyValidationFailed = "validation_failed"
+
+var matcher = language.NewMatcher([]language.Tag{language.English, language.Indonesian})
+
+// Messages use {field} and {param} placeholders. A rule without an entry
+// falls back to "invalid".
+var messages = map[string]map[string]string{
+	LocaleEnglish: {
+		keyValidationFailed: "request validation failed",
+		"invalid":           "{field} is invalid",
+		"type":              "{field} must be a {param}",
+		"required":          "{field} is required",
+		"email":             "{field} must be a valid email address",
+		"oneof":             "{field} must be one of: {param}",
+		"min":               "{field} must be at least {param}",
+		"min.string":        "{field} must be at least {param} characters",
+		"min.items":         "{field} must contain at least {param} items",
+		"max":               "{field} must be at most {param}",
+		"max.string":        "{field} must be at most {param} characters",
+		"max.items":         "{field} must contain at most {param} items",
+		"len":               "{field} must be {param}",
+		"len.string":        "{field} must be exactly {param} characters",
+		"len.items":         "{field} must contain exactly {param} items",
+		"gt":                "{field} must be greater than {param}",
+		"gte":               "{field} must be greater than or equal to {param}",
+		"lt":                "{field} must be less than {param}",
+		"lte":               "{field} must be less than or equal to {param}",
+	},
+	LocaleIndonesian: {
+		keyValidationFailed: "validasi permintaan gagal",
+		"invalid":           "{field} tidak valid",
+		"type":              "{field} harus bertipe {param}",
+		"required":          "{field} wajib diisi",
+		"email":             "{field} harus berupa alamat email yang valid",
+		"oneof":             "{field} harus salah satu dari: {param}",
+		"min":               "{field} minimal {param}",
+		"min.string":        "{field} minimal {param} karakter",
+		"min.items":         "{field} minimal berisi {param} item",
+		"max":               "{field} maksimal {param}",
+		"max.string":        "{field} maksimal {param} karakter",
+		"max.items":         "{field} maksimal berisi {param} item",
+		"len":               "{field} harus sama dengan {param}",
+		"len.string":        "{field} harus tepat {param} karakter",
+		"len.items":         "{field} harus berisi tepat {param} item",
+		"gt":                "{field} harus lebih besar dari {param}",
+		"gte":               "{field} harus lebih besar dari atau sama dengan {param}",
+		"lt":                "{field} harus lebih kecil dari {param}",
+		"lte":               "{field} harus lebih kecil dari atau sama dengan {param}",
+	},
+}
+
+// Locale picks the response language from the Accept-Language header.
+func Locale(c *gin.Context) string {
+	tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return LocaleEnglish
+	}
+	_, i, _ := matcher.Match(tags...)
+	if i == 1 {
+		return LocaleIndonesian
+	}
+	return LocaleEnglish
+}
+
+func translate(lang, key, field, param string) string {
+	table, ok := messages[lang]
+	if !ok {
+		table = messages[LocaleEnglish]
+	}
+	msg, ok := table[key]
+	if !ok {
+		if msg, ok = messages[LocaleEnglish][key]; !ok {
+			msg = table["invalid"]
+		}
+	}
+	return strings.NewReplacer("{field}", field, "{param}", param).Replace(msg)
+}
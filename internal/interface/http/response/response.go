@@ -0,0 +1,140 @@
+// Package response holds the JSON envelopes every endpoint answers with.
+//
+// Successful responses are {"data": ..., "meta": ...}; errors are
+// {"error": {"code", "message", "details"}} or, on request, an RFC 7807
+// problem document. Handlers call the success helpers directly and hand
+// errors to c.Error; middleware.ErrorHandler renders them through Error.
+package response
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/render"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+const problemContentType = "application/problem+json"
+
+// Pagination defaults used when the client sends none or invalid values.
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+type Envelope struct {
+	Data any   `json:"data"`
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+type Meta struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// NewMeta normalizes page and perPage and computes the page count.
+func NewMeta(page, perPage, total int) *Meta {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = DefaultPerPage
+	}
+	if perPage > MaxPerPage {
+		perPage = MaxPerPage
+	}
+	return &Meta{
+		Page:       page,
+		PerPage:    perPage,
+		Total:      total,
+		TotalPages: (total + perPage - 1) / perPage,
+	}
+}
+
+// Offset returns how many items come before the current page, for callers
+// that fetch it with LIMIT PerPage OFFSET Offset().
+func (m *Meta) Offset() int {
+	return (m.Page - 1) * m.PerPage
+}
+
+func OK(c *gin.Context, data any) {
+	c.JSON(http.StatusOK, Envelope{Data: data})
+}
+
+func Created(c *gin.Context, data any) {
+	c.JSON(http.StatusCreated, Envelope{Data: data})
+}
+
+func NoContent(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+// Paginated writes one page of a list together with its metadata.
+func Paginated(c *gin.Context, data any, meta *Meta) {
+	c.JSON(http.StatusOK, Envelope{Data: data, Meta: meta})
+}
+
+// Error writes err in the default error envelope.
+func Error(c *gin.Context, err *apperr.AppError) {
+	c.JSON(err.Status, ErrorEnvelope{Error: ErrorBody{
+		Code:    err.Code,
+		Message: message(c, err),
+		Details: details(c, err),
+	}})
+}
+
+// Problem writes err as an RFC 7807 problem document.
+func Problem(c *gin.Context, err *apperr.AppError) {
+	body := gin.H{
+		"type":     "urn:mms:error:" + strings.ToLower(err.Code),
+		"title":    http.StatusText(err.Status),
+		"status":   err.Status,
+		"detail":   message(c, err),
+		"instance": c.Request.URL.Path,
+		"code":     err.Code,
+	}
+	if d := details(c, err); d != nil {
+		body["details"] = d
+	}
+	c.Header("Content-Type", problemContentType)
+	c.Render(err.Status, render.JSON{Data: body})
+}
+
+// WantsProblem reports whether the client asked for application/problem+json.
+func WantsProblem(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), problemContentType)
+}
+
+// message localizes the message of validation errors; all other messages
+// are rendered as registered.
+func message(c *gin.Context, err *apperr.AppError) string {
+	if err.Code == apperr.CodeValidation && err.Details == nil {
+		return translate(Locale(c), keyValidationFailed, "", "")
+	}
+	return err.Message
+}
+
+// details translates binding failures into per-field messages in the
+// client's language.
+func details(c *gin.Context, err *apperr.AppError) any {
+	if err.Details != nil || err.Code != apperr.CodeValidation {
+		return err.Details
+	}
+	if fields := FieldErrors(err.Err, Locale(c)); len(fields) > 0 {
+		return fields
+	}
+	return nil
+}
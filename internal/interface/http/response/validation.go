@@ -0,0 +1,142 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+var (
+	errInvalidRequest = apperr.BadRequest("INVALID_REQUEST", "invalid request body")
+	errValidation     = apperr.BadRequest(apperr.CodeValidation, "request validation failed")
+)
+
+// FieldError is one failed rule on one request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Validation errors report the json (or form) name of a field rather than
+// the Go one, so clients see the names they sent.
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldName)
+	}
+}
+
+func fieldName(f reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(f.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// BindError wraps a ShouldBind failure. Rule and type failures become a
+// VALIDATION_FAILED error whose per-field details are translated when the
+// response is rendered; anything else, e.g. malformed JSON, is an
+// INVALID_REQUEST.
+func BindError(err error) *apperr.AppError {
+	var verrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &verrs) || errors.As(err, &typeErr) {
+		return errValidation.Wrap(err)
+	}
+	return errInvalidRequest.WithDetails(err.Error()).Wrap(err)
+}
+
+// FieldErrors translates the validation failures in err into lang. It
+// returns nil when err carries none.
+func FieldErrors(err error, lang string) []FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field := typeErr.Field
+		if field == "" {
+			field = "body"
+		}
+		return []FieldError{{
+			Field:   field,
+			Rule:    "type",
+			Message: translate(lang, "type", field, jsonType(typeErr.Type)),
+		}}
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+	fields := make([]FieldError, len(verrs))
+	for i, fe := range verrs {
+		field := fieldPath(fe)
+		fields[i] = FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: translate(lang, messageKey(fe), field, param(fe)),
+		}
+	}
+	return fields
+}
+
+// fieldPath drops the struct name from the namespace, so nested and dived
+// fields read "scopes[0]" instead of "CreateAPIKeyRequest.scopes[0]".
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// messageKey picks the message variant for rules whose wording depends on
+// the kind of value, e.g. "at least 8 characters" versus "at least 8".
+// Conditional required rules read like required.
+func messageKey(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required_if", "required_unless":
+		return "required"
+	case "min", "max", "len":
+	default:
+		return fe.Tag()
+	}
+	switch fe.Kind() {
+	case reflect.String:
+		return fe.Tag() + ".string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return fe.Tag() + ".items"
+	default:
+		return fe.Tag()
+	}
+}
+
+func param(fe validator.FieldError) string {
+	if fe.Tag() == "oneof" {
+		return strings.Join(strings.Fields(fe.Param()), ", ")
+	}
+	return fe.Param()
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "number"
+	}
+}
@@ -1,28 +1,119 @@
 package http
 
 import (
+	"fmt"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/metrics"
 	"github.com/luthfiarsyad/mms/internal/interface/http/handler"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
 )
 
-func SetupRoutes(r *gin.Engine) {
-	r.Use(gin.Recovery())
+// Handlers are what the routes are served by. They are assembled by the
+// app package; tests may fill in their own.
+type Handlers struct {
+	Health       *handler.HealthHandler
+	Admin        *handler.AdminHandler
+	Auth         *handler.AuthHandler
+	APIKeys      *handler.APIKeyHandler
+	Sessions     *handler.SessionHandler
+	Transactions *handler.TransactionHandler
+	Audit        *handler.AuditHandler
+
+	// RequireAuth authenticates /api requests by token or API key.
+	RequireAuth gin.HandlerFunc
+	// Idempotency replays the stored response to a retried request; see
+	// middleware.Idempotency.
+	Idempotency gin.HandlerFunc
+}
+
+// SetupRoutes registers every route on r as configured in cfg. lc reports
+// shutdown, during which readiness fails so no new traffic is routed here.
+func SetupRoutes(r *gin.Engine, cfg *config.Config, h *Handlers, lc *lifecycle.Manager) error {
+	// gin trusts every proxy unless told otherwise, which would let any
+	// client pick its own address with X-Forwarded-For.
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid server.trusted_proxies: %w", err)
+	}
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+	// CORS and rate limits follow config reloads.
+	cors := middleware.NewCORS(cfg.CORS)
+	limiter := middleware.NewRateLimiter(cfg.RateLimit)
+	stopCORS := config.OnChange(func(c *config.Config) config.CORSConfig { return c.CORS }, cors.Update)
+	stopLimiter := config.OnChange(func(c *config.Config) config.RateLimitConfig { return c.RateLimit }, limiter.Update)
+	lc.OnClose("config subscribers", func() error {
+		stopCORS()
+		stopLimiter()
+		return nil
 	})
 
-	api := r.Group("/api")
+	r.Use(middleware.Tracing())
+	r.Use(middleware.RequestID())
+	r.Use(metrics.Middleware())
+	r.Use(gin.Recovery())
+	r.Use(middleware.ErrorHandler(cfg.Server.ProblemDetails))
+	// Preflight requests match no route, so CORS must be global.
+	r.Use(cors.Handler())
+
+	r.GET("/livez", h.Health.Livez)
+	r.GET("/readyz", h.Health.Readyz)
+	r.GET("/health", h.Health.Readyz) // kept for existing monitors
+
+	if mc := cfg.Metrics; mc.Enabled {
+		path := mc.Path
+		if path == "" {
+			path = "/metrics"
+		}
+		var chain []gin.HandlerFunc
+		if mc.Username != "" {
+			chain = append(chain, middleware.BasicAuth(mc.Username, mc.Password, "metrics"))
+		}
+		r.GET(path, append(chain, gin.WrapH(metrics.Handler()))...)
+	}
+
+	if ac := cfg.Admin; ac.Username != "" {
+		// Limited before basic auth, so failed logins count too.
+		admin := r.Group("/admin", limiter.Handler(), middleware.BasicAuth(ac.Username, ac.Password, "admin"))
+		admin.GET("/log-level", h.Admin.GetLogLevel)
+		admin.PUT("/log-level", h.Admin.SetLogLevel)
+		admin.GET("/audit/verify", h.Admin.VerifyAudit)
+		admin.PUT("/users/:id/password", h.Admin.ResetPassword)
+	}
+
+	// Probes and scrapes are not rate limited.
+	api := r.Group("/api", limiter.Handler())
 	v1 := api.Group("/v1")
 
+	requireAuth := h.RequireAuth
+	canRead := middleware.RequireScope(apikey.ScopeTransactionsRead)
+	canWrite := middleware.RequireScope(apikey.ScopeTransactionsWrite)
+
 	// --- AUTH ROUTES ---
-	authHandler := handler.NewAuthHandler()
 	auth := v1.Group("/auth")
 	{
-		auth.POST("/register", authHandler.Register)
-		auth.POST("/login", authHandler.Login)
+		auth.POST("/register", h.Auth.Register)
+		auth.POST("/login", h.Auth.Login)
+		auth.PUT("/password", requireAuth, middleware.DenyAPIKeys(), h.Auth.ChangePassword)
 	}
 
+	// --- CURRENT USER ROUTES ---
+	me := v1.Group("/me", requireAuth, middleware.DenyAPIKeys(), h.Idempotency)
+	{
+		me.POST("/api-keys", h.APIKeys.Create)
+		me.GET("/api-keys", h.APIKeys.List)
+		me.DELETE("/api-keys/:id", h.APIKeys.Revoke)
+		me.GET("/sessions", h.Sessions.List)
+		me.DELETE("/sessions/:id", h.Sessions.Revoke)
+	}
+
+	// --- AUDIT ROUTES ---
+	v1.GET("/audit", requireAuth, middleware.DenyAPIKeys(), h.Audit.List)
+
 	// --- USERS ROUTES ---
 	users := v1.Group("/users")
 	{
@@ -34,19 +125,25 @@ func SetupRoutes(r *gin.Engine) {
 	}
 
 	// --- TRANSACTIONS ROUTES ---
-	tx := v1.Group("/transactions")
+	tx := v1.Group("/transactions", requireAuth, h.Idempotency)
 	{
-		tx.POST("", createTransaction)
-		tx.GET("", listTransactions)
-		tx.GET("/:id", getTransaction)
+		tx.POST("", canWrite, h.Transactions.Create)
+		tx.POST("/batch", canWrite, h.Transactions.Batch)
+		tx.GET("", canRead, h.Transactions.List)
+		tx.GET("/trash", canRead, h.Transactions.Trash)
+		tx.GET("/:id", canRead, h.Transactions.Get)
+		tx.PUT("/:id", canWrite, h.Transactions.Update)
+		tx.PATCH("/:id", canWrite, h.Transactions.Patch)
+		tx.DELETE("/:id", canWrite, h.Transactions.Delete)
+		tx.POST("/:id/restore", canWrite, h.Transactions.Restore)
 	}
+	return nil
 }
 
-func createUser(c *gin.Context)        { c.JSON(501, gin.H{"error": "not implemented"}) }
-func listUsers(c *gin.Context)         { c.JSON(501, gin.H{"error": "not implemented"}) }
-func getUser(c *gin.Context)           { c.JSON(501, gin.H{"error": "not implemented"}) }
-func updateUser(c *gin.Context)        { c.JSON(501, gin.H{"error": "not implemented"}) }
-func deleteUser(c *gin.Context)        { c.JSON(501, gin.H{"error": "not implemented"}) }
-func createTransaction(c *gin.Context) { c.JSON(501, gin.H{"error": "not implemented"}) }
-func listTransactions(c *gin.Context)  { c.JSON(501, gin.H{"error": "not implemented"}) }
-func getTransaction(c *gin.Context)    { c.JSON(501, gin.H{"error": "not implemented"}) }
+var errNotImplemented = apperr.New(apperr.CodeNotImplemented, http.StatusNotImplemented, "not implemented")
+
+func createUser(c *gin.Context) { _ = c.Error(errNotImplemented) }
+func listUsers(c *gin.Context)  { _ = c.Error(errNotImplemented) }
+func getUser(c *gin.Context)    { _ = c.Error(errNotImplemented) }
+func updateUser(c *gin.Context) { _ = c.Error(errNotImplemented) }
+func deleteUser(c *gin.Context) { _ = c.Error(errNotImplemented) }
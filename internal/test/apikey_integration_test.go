@@ -0,0 +1,140 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+)
+
+func TestAPIKeyIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	userID := helper.CreateTestUser("Script User", "script@example.com", "unused")
+	token := helper.CreateTestToken(userID)
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+
+	do := func(method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+		return performJSON(t, router, method, path, body, headers)
+	}
+	bearer := map[string]string{"Authorization": "Bearer " + token}
+
+	// Create a read-only key
+	w := do("POST", "/api/v1/me/api-keys", request.CreateAPIKeyRequest{
+		Name:   "reporting script",
+		Scopes: []string{apikey.ScopeTransactionsRead},
+	}, bearer)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Data struct {
+			Key    string        `json:"key"`
+			APIKey apikey.APIKey `json:"api_key"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	created := resp.Data
+	assert.True(t, apikey.IsAPIKey(created.Key))
+	assert.Contains(t, created.Key, created.APIKey.Prefix)
+
+	t.Run("Key is accepted through X-API-Key and Bearer", func(t *testing.T) {
+		w := do("GET", "/api/v1/transactions", nil, map[string]string{"X-API-Key": created.Key})
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = do("GET", "/api/v1/transactions", nil, map[string]string{"Authorization": "Bearer " + created.Key})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Key without write scope cannot create transactions", func(t *testing.T) {
+		w := do("POST", "/api/v1/transactions", request.CreateTransactionRequest{
+			Amount: 10, Description: "coffee", Type: "expense",
+		}, map[string]string{"X-API-Key": created.Key})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Key cannot manage keys", func(t *testing.T) {
+		w := do("GET", "/api/v1/me/api-keys", nil, map[string]string{"X-API-Key": created.Key})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Key cannot read the audit log", func(t *testing.T) {
+		w := do("GET", "/api/v1/audit", nil, map[string]string{"X-API-Key": created.Key})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Listed keys never expose the hash", func(t *testing.T) {
+		w := do("GET", "/api/v1/me/api-keys", nil, bearer)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "key_hash")
+		assert.Contains(t, w.Body.String(), created.APIKey.Prefix)
+	})
+
+	t.Run("Revoked key is rejected", func(t *testing.T) {
+		w := do("DELETE", fmt.Sprintf("/api/v1/me/api-keys/%d", created.APIKey.ID), nil, bearer)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		w = do("GET", "/api/v1/transactions", nil, map[string]string{"X-API-Key": created.Key})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+}
+
+// touchCountingRepo counts last-use writes and fails them while err is set.
+type touchCountingRepo struct {
+	apikey.Repository
+	touches int
+	err     error
+}
+
+func (r *touchCountingRepo) TouchLastUsed(ctx context.Context, id int64, at time.Time) error {
+	r.touches++
+	if r.err != nil {
+		return r.err
+	}
+	return r.Repository.TouchLastUsed(ctx, id, at)
+}
+
+func TestAPIKeyLastUsed(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Script", Email: "touch@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+	repo := &touchCountingRepo{Repository: repos.APIKeys}
+	keys := usecase.NewAPIKeyUsecase(apikey.NewService(repo))
+	_, raw, err := keys.CreateKey(ctx, u.ID, "script", []string{apikey.ScopeTransactionsRead}, nil)
+	require.NoError(t, err)
+
+	repo.err = errors.New("database is read-only")
+	k, err := keys.AuthenticateAPIKey(ctx, raw)
+	require.NoError(t, err, "a failed last-use write does not reject the key")
+	assert.Nil(t, k.LastUsedAt)
+	assert.Equal(t, 1, repo.touches)
+
+	repo.err = nil
+	k, err = keys.AuthenticateAPIKey(ctx, raw)
+	require.NoError(t, err)
+	assert.NotNil(t, k.LastUsedAt)
+	for range 3 {
+		_, err = keys.AuthenticateAPIKey(ctx, raw)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, repo.touches, "last use is written at most once a minute")
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
+	apperr "github.com/luthfiarsyad/mms/pkg/errors"
+)
+
+func TestAppError_Unit(t *testing.T) {
+	errSentinel := errors.New("widget not found")
+	errWidgetNotFound := apperr.NotFound("WIDGET_NOT_FOUND", "widget not found")
+	apperr.Register(errSentinel, errWidgetNotFound)
+
+	t.Run("Registered sentinel maps onto its AppError", func(t *testing.T) {
+		app := apperr.From(fmt.Errorf("load widget: %w", errSentinel))
+
+		assert.Equal(t, "WIDGET_NOT_FOUND", app.Code)
+		assert.Equal(t, http.StatusNotFound, app.Status)
+		assert.ErrorIs(t, app, errSentinel)
+		assert.ErrorIs(t, app, errWidgetNotFound)
+	})
+
+	t.Run("Unknown errors become internal errors with a safe message", func(t *testing.T) {
+		app := apperr.From(errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+
+		assert.Equal(t, apperr.CodeInternal, app.Code)
+		assert.Equal(t, http.StatusInternalServerError, app.Status)
+		assert.Equal(t, "internal server error", app.Message)
+	})
+
+	t.Run("Copies keep matching the sentinel", func(t *testing.T) {
+		wrapped := errWidgetNotFound.WithDetails("id=1").Wrap(errors.New("cause"))
+
+		assert.ErrorIs(t, wrapped, errWidgetNotFound)
+		assert.Nil(t, errWidgetNotFound.Details)
+	})
+
+	t.Run("ErrorHandler renders JSON and problem+json", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.Use(middleware.ErrorHandler(false))
+		router.GET("/widgets/1", func(c *gin.Context) {
+			_ = c.Error(fmt.Errorf("lookup: %w", errSentinel))
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/widgets/1", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var response errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "WIDGET_NOT_FOUND", response.Error.Code)
+
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest("GET", "/widgets/1", nil)
+		req.Header.Set("Accept", "application/problem+json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		var problem map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, float64(http.StatusNotFound), problem["status"])
+		assert.Equal(t, "WIDGET_NOT_FOUND", problem["code"])
+		assert.Equal(t, "/widgets/1", problem["instance"])
+	})
+}
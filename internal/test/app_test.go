@@ -0,0 +1,51 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/app"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestNewHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	valid := config.Config{
+		Paseto:   config.PasetoConfig{SymmetricKey: testPasetoKey, ExpireMinutes: 60},
+		Password: config.PasswordConfig{Algorithm: "bcrypt"},
+	}
+
+	t.Run("Invalid settings are errors, not panics", func(t *testing.T) {
+		badKey := valid
+		badKey.Paseto.SymmetricKey = "c2hvcnQ="
+		_, err := app.NewHandlers(&badKey, store.NewMemory(), testLifecycle())
+		assert.ErrorContains(t, err, "invalid PASETO key length")
+
+		badHasher := valid
+		badHasher.Password.Algorithm = "md5"
+		_, err = app.NewHandlers(&badHasher, store.NewMemory(), testLifecycle())
+		assert.ErrorContains(t, err, "password hasher")
+	})
+
+	t.Run("Handlers serve from the repositories they are given", func(t *testing.T) {
+		repos := store.NewMemory()
+		lc := testLifecycle()
+		defer lc.Stop(t.Context())
+		router := gin.New()
+		require.NoError(t, app.Routes(router, &valid, repos, lc))
+
+		w := performJSON(t, router, "POST", "/api/v1/auth/register", request.RegisterRequest{
+			Name: "Wired User", Email: "wired@example.com", Password: "password123",
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		_, err := repos.Users.FindByEmail(t.Context(), "wired@example.com")
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,283 @@
+package test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/migration"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/sqlite"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+	"github.com/luthfiarsyad/mms/pkg/actor"
+	"github.com/luthfiarsyad/mms/pkg/requestid"
+)
+
+func TestAuditLog(t *testing.T) {
+	router, _ := newMemoryServerWithConfig(t, `
+admin:
+  username: "ops"
+  password: "s3cret"
+`)
+	owner := registerAndLogin(t, router, "audited@example.com")
+	other := registerAndLogin(t, router, "bystander@example.com")
+
+	// Unlike performJSON, httptest.NewRequest sets a client address.
+	req := httptest.NewRequest("POST", "/api/v1/transactions", strings.NewReader(`{"amount":30,"description":"groceries","type":"expense"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", owner["Authorization"])
+	req.Header.Set(requestid.Header, "req-create-1")
+	req.Header.Set("X-Forwarded-For", "203.0.113.9")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data transaction.Transaction `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := fmt.Sprintf("/api/v1/transactions/%d", created.Data.ID)
+
+	ifMatch := map[string]string{"Authorization": owner["Authorization"], "If-Match": w.Header().Get("ETag")}
+	w = performJSON(t, router, "PUT", path, request.UpdateTransactionRequest{Amount: 45, Description: "groceries", Type: "expense"}, ifMatch)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, http.StatusNoContent, performJSON(t, router, "DELETE", path, nil, owner).Code)
+	require.Equal(t, http.StatusOK, performJSON(t, router, "POST", path+"/restore", nil, owner).Code)
+	w = performJSON(t, router, "PUT", "/api/v1/auth/password", request.ChangePasswordRequest{
+		CurrentPassword: "password123", NewPassword: "a-new-password-456",
+	}, owner)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	list := func(query string, auth map[string]string) []audit.Record {
+		t.Helper()
+		w := performJSON(t, router, "GET", "/api/v1/audit"+query, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page struct {
+			Data []audit.Record `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page.Data
+	}
+
+	t.Run("Every change is recorded", func(t *testing.T) {
+		records := list("", owner)
+		require.Len(t, records, 6)
+		var actions []string
+		for _, r := range records {
+			actions = append(actions, r.EntityType+" "+string(r.Action))
+		}
+		assert.Equal(t, []string{
+			"user update", "transaction restore", "transaction delete",
+			"transaction update", "transaction create", "user create",
+		}, actions, "newest first")
+
+		password, registration := records[0], records[5]
+		assert.NotContains(t, string(password.Before)+string(password.After), "$2a$", "password hashes are never recorded")
+		assert.JSONEq(t, `{"password":"[changed]"}`, string(password.After))
+		assert.Equal(t, registration.EntityID, registration.ActorID, "a new user registers themselves")
+		assert.Nil(t, registration.Before)
+
+		create, update := records[4], records[3]
+		assert.Equal(t, "req-create-1", create.RequestID)
+		assert.Equal(t, "192.0.2.1", create.IP, "X-Forwarded-For from an untrusted peer is ignored")
+		assert.Equal(t, created.Data.ID, create.EntityID)
+		assert.JSONEq(t, `30`, string(mustField(t, create.After, "amount")))
+		assert.JSONEq(t, `30`, string(mustField(t, update.Before, "amount")))
+		assert.JSONEq(t, `45`, string(mustField(t, update.After, "amount")))
+		assert.NotContains(t, string(update.After), "description", "unchanged fields are left out")
+		assert.Contains(t, string(records[2].After), "deleted_at")
+		assert.Contains(t, string(records[1].Before), "deleted_at")
+	})
+
+	t.Run("Filters by entity and date", func(t *testing.T) {
+		byEntity := list(fmt.Sprintf("?entity=transaction&entity_id=%d", created.Data.ID), owner)
+		assert.Len(t, byEntity, 4)
+		assert.Len(t, list("?entity=user", owner), 2)
+
+		today := time.Now().UTC().Format("2006-01-02")
+		yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+		assert.Len(t, list("?from="+today+"&to="+today, owner), 6, "a date in to includes the whole day")
+		assert.Empty(t, list("?to="+yesterday, owner))
+		assert.Empty(t, list("?from="+time.Now().Add(time.Hour).UTC().Format(time.RFC3339), owner))
+
+		assert.Equal(t, http.StatusBadRequest, performJSON(t, router, "GET", "/api/v1/audit?from=yesterday", nil, owner).Code)
+		assert.Equal(t, http.StatusBadRequest, performJSON(t, router, "GET", "/api/v1/audit?entity=session", nil, owner).Code)
+	})
+
+	t.Run("Pages come from the database", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/api/v1/audit?page=2&per_page=4", nil, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page struct {
+			Data []audit.Record `json:"data"`
+			Meta response.Meta  `json:"meta"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		require.Len(t, page.Data, 2)
+		assert.Equal(t, "transaction create", page.Data[0].EntityType+" "+string(page.Data[0].Action))
+		assert.Equal(t, response.Meta{Page: 2, PerPage: 4, Total: 6, TotalPages: 2}, page.Meta)
+		assert.Empty(t, list("?page=3&per_page=4", owner))
+	})
+
+	t.Run("Users see only their own changes", func(t *testing.T) {
+		records := list("", other)
+		require.Len(t, records, 1)
+		assert.Equal(t, audit.EntityUser, records[0].EntityType)
+		assert.Equal(t, http.StatusUnauthorized, performJSON(t, router, "GET", "/api/v1/audit", nil, nil).Code)
+	})
+
+	t.Run("Admins verify the chain", func(t *testing.T) {
+		auth := map[string]string{
+			"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("ops:s3cret")),
+		}
+		w := performJSON(t, router, "GET", "/admin/audit/verify", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.JSONEq(t, `{"data":{"intact":true,"checked":7}}`, w.Body.String())
+	})
+}
+
+func mustField(t *testing.T, raw json.RawMessage, name string) json.RawMessage {
+	t.Helper()
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(raw, &fields))
+	require.Contains(t, fields, name)
+	return fields[name]
+}
+
+// failingAuditRepo fails every append, so the change it should record must
+// not be stored either.
+type failingAuditRepo struct {
+	audit.Repository
+}
+
+var errAuditDown = errors.New("audit log unavailable")
+
+func (failingAuditRepo) Append(context.Context, *audit.Record) error {
+	return errAuditDown
+}
+
+func TestAuditIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Atomic", Email: "atomic@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+
+	audits := audit.NewService(failingAuditRepo{repos.Audit}, repos.Transactor)
+	service := transaction.NewService(repos.Transactions, audits)
+
+	tx := &transaction.Transaction{UserID: u.ID, Amount: 10, Description: "unaudited", Type: "income"}
+	assert.ErrorIs(t, service.Create(ctx, tx), errAuditDown)
+	list, err := repos.Transactions.FindByUserID(ctx, u.ID, transaction.Page{})
+	require.NoError(t, err)
+	assert.Empty(t, list, "a change that cannot be audited is not stored")
+
+	err = user.NewService(repos.Users, audits).Register(ctx, &user.User{Name: "Ghost", Email: "ghost@example.com", Password: "hash"})
+	assert.ErrorIs(t, err, errAuditDown)
+	_, err = repos.Users.FindByEmail(ctx, "ghost@example.com")
+	assert.Error(t, err)
+}
+
+func TestAuditTamperDetection(t *testing.T) {
+	ctx := context.Background()
+
+	// newChain writes three records to a fresh SQLite database and removes
+	// the triggers that keep it append-only, as someone with direct database
+	// access could.
+	newChain := func(t *testing.T) (*store.Store, *audit.Service) {
+		t.Helper()
+		db, err := sqlite.Open(ctx, filepath.Join(t.TempDir(), "mms.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		repos := store.NewSQLite(db)
+		audits := audit.NewService(repos.Audit, repos.Transactor)
+		actorCtx := actor.NewContext(ctx, 1)
+		for i := int64(1); i <= 3; i++ {
+			require.NoError(t, audits.Apply(actorCtx, func(context.Context) (*audit.Change, error) {
+				return &audit.Change{Action: audit.ActionCreate, EntityType: audit.EntityTransaction, EntityID: i, After: map[string]any{"amount": i}}, nil
+			}))
+		}
+		checked, err := audits.Verify(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 3, checked)
+
+		for _, trigger := range []string{"audit_log_no_update", "audit_log_no_delete"} {
+			_, err := db.Exec("DROP TRIGGER " + trigger)
+			require.NoError(t, err)
+		}
+		return repos, audits
+	}
+
+	tests := []struct {
+		name    string
+		tamper  string
+		problem string
+	}{
+		{"Edited record", `UPDATE audit_log SET after_data = '{"amount":1000}' WHERE id = 2`, "record 2 was modified"},
+		{"Edited hash", `UPDATE audit_log SET hash = '` + fmt.Sprintf("%064d", 0) + `' WHERE id = 2`, "record 2 was modified"},
+		{"Removed record", `DELETE FROM audit_log WHERE id = 2`, "record 3 does not follow"},
+		{"Removed newest record", `DELETE FROM audit_log WHERE id = 3`, "records after 2 are missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos, audits := newChain(t)
+			_, err := repos.DB.Exec(tt.tamper)
+			require.NoError(t, err)
+
+			_, err = audits.Verify(ctx)
+			assert.ErrorIs(t, err, audit.ErrTampered)
+			assert.ErrorContains(t, err, tt.problem)
+		})
+	}
+}
+
+func TestAuditDiff(t *testing.T) {
+	before, after, err := audit.Diff(
+		map[string]any{"amount": 1, "type": "income", "note": "old"},
+		map[string]any{"amount": 2, "type": "income", "tag": "new"},
+	)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":1,"note":"old"}`, string(before))
+	assert.JSONEq(t, `{"amount":2,"tag":"new"}`, string(after))
+
+	before, after, err = audit.Diff(nil, map[string]any{"amount": 1})
+	require.NoError(t, err)
+	assert.Nil(t, before)
+	assert.JSONEq(t, `{"amount":1}`, string(after), "a create keeps the whole entity")
+
+	_, _, err = audit.Diff(nil, []int{1})
+	assert.Error(t, err, "entities must encode to JSON objects")
+}
+
+func TestMigrationStatements(t *testing.T) {
+	m := migration.Migration{SQL: `CREATE TABLE a (id INT);
+INSERT INTO a VALUES (1);
+
+-- statement begin
+CREATE TRIGGER t BEFORE DELETE ON a FOR EACH ROW
+BEGIN
+SIGNAL SQLSTATE '45000';
+END;
+-- statement end
+
+CREATE INDEX i ON a (id);
+`}
+	assert.Equal(t, []string{
+		"CREATE TABLE a (id INT)",
+		"INSERT INTO a VALUES (1)",
+		"CREATE TRIGGER t BEFORE DELETE ON a FOR EACH ROW\nBEGIN\nSIGNAL SQLSTATE '45000';\nEND",
+		"CREATE INDEX i ON a (id)",
+	}, m.Statements())
+}
@@ -13,9 +13,11 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
 	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/memory"
 	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/mysql"
-	httpInterface "github.com/luthfiarsyad/mms/internal/interface/http"
 	"github.com/luthfiarsyad/mms/internal/interface/http/request"
 )
 
@@ -27,17 +29,15 @@ func TestAuthIntegration(t *testing.T) {
 	db := SetupTestDatabase(t)
 	defer CleanupTestDatabase(t, db)
 
-	// Set the global DB instance for the handlers to use
-	mysql.DB = db
-
 	// Setup repositories and services
 	userRepo := mysql.NewUserRepo(db)
-	userService := user.NewService(userRepo)
+	audits := audit.NewService(mysql.NewAuditRepo(db), persistence.NewTransactor(db))
+	userService := user.NewService(userRepo, audits)
 
 	t.Run("Health endpoint works", func(t *testing.T) {
 		// Arrange
 		router := gin.New()
-		httpInterface.SetupRoutes(router)
+		mountRoutes(t, router, db, testLifecycle())
 
 		req, _ := http.NewRequest("GET", "/health", nil)
 		w := httptest.NewRecorder()
@@ -54,10 +54,23 @@ func TestAuthIntegration(t *testing.T) {
 		assert.Equal(t, "ok", response["status"])
 	})
 
+	t.Run("Health endpoint fails once shutdown begins", func(t *testing.T) {
+		lc := testLifecycle()
+		router := gin.New()
+		mountRoutes(t, router, db, lc)
+		lc.BeginShutdown()
+
+		req, _ := http.NewRequest("GET", "/health", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
 	t.Run("User registration through handler", func(t *testing.T) {
 		// Arrange
 		router := gin.New()
-		httpInterface.SetupRoutes(router)
+		mountRoutes(t, router, db, testLifecycle())
 
 		registerReq := request.RegisterRequest{
 			Name:     "Test User",
@@ -76,9 +89,12 @@ func TestAuthIntegration(t *testing.T) {
 		// Assert
 		assert.Equal(t, http.StatusCreated, w.Code)
 
-		var response map[string]interface{}
-		err := json.Unmarshal(w.Body.Bytes(), &response)
+		var envelope struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &envelope)
 		require.NoError(t, err)
+		response := envelope.Data
 		assert.Contains(t, response, "id")
 		assert.Contains(t, response, "email")
 		assert.Contains(t, response, "created_at")
@@ -102,7 +118,7 @@ func TestAuthIntegration(t *testing.T) {
 
 		// Now test login through handler
 		router := gin.New()
-		httpInterface.SetupRoutes(router)
+		mountRoutes(t, router, db, testLifecycle())
 
 		loginReq := request.LoginRequest{
 			Email:    "login@example.com",
@@ -120,9 +136,12 @@ func TestAuthIntegration(t *testing.T) {
 		// Assert
 		assert.Equal(t, http.StatusOK, w.Code)
 
-		var response map[string]interface{}
-		err = json.Unmarshal(w.Body.Bytes(), &response)
+		var envelope struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &envelope)
 		require.NoError(t, err)
+		response := envelope.Data
 		assert.Contains(t, response, "access_token")
 		assert.Contains(t, response, "token_type")
 		assert.Contains(t, response, "expires_in")
@@ -133,7 +152,7 @@ func TestAuthIntegration(t *testing.T) {
 	t.Run("Login with wrong credentials fails", func(t *testing.T) {
 		// Arrange
 		router := gin.New()
-		httpInterface.SetupRoutes(router)
+		mountRoutes(t, router, db, testLifecycle())
 
 		loginReq := request.LoginRequest{
 			Email:    "nonexistent@example.com",
@@ -151,10 +170,11 @@ func TestAuthIntegration(t *testing.T) {
 		// Assert
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 
-		var response map[string]interface{}
+		var response errorResponse
 		err := json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
-		assert.Equal(t, "invalid credentials", response["error"])
+		assert.Equal(t, "INVALID_CREDENTIALS", response.Error.Code)
+		assert.Equal(t, "invalid credentials", response.Error.Message)
 	})
 
 	t.Run("Registration with duplicate email fails", func(t *testing.T) {
@@ -171,7 +191,7 @@ func TestAuthIntegration(t *testing.T) {
 
 		// Now try to register the same email through handler
 		router := gin.New()
-		httpInterface.SetupRoutes(router)
+		mountRoutes(t, router, db, testLifecycle())
 
 		registerReq := request.RegisterRequest{
 			Name:     "Another User",
@@ -188,18 +208,19 @@ func TestAuthIntegration(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		// Assert
-		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusConflict, w.Code)
 
-		var response map[string]interface{}
+		var response errorResponse
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
-		assert.Contains(t, response, "error")
+		assert.Equal(t, "EMAIL_TAKEN", response.Error.Code)
+		assert.NotContains(t, w.Body.String(), "Duplicate entry")
 	})
 
 	t.Run("Non-implemented endpoints return 501", func(t *testing.T) {
 		// Arrange
 		router := gin.New()
-		httpInterface.SetupRoutes(router)
+		mountRoutes(t, router, db, testLifecycle())
 
 		testCases := []struct {
 			method string
@@ -210,9 +231,6 @@ func TestAuthIntegration(t *testing.T) {
 			{"GET", "/api/v1/users/1"},
 			{"PUT", "/api/v1/users/1"},
 			{"DELETE", "/api/v1/users/1"},
-			{"GET", "/api/v1/transactions"},
-			{"POST", "/api/v1/transactions"},
-			{"GET", "/api/v1/transactions/1"},
 		}
 
 		for _, tc := range testCases {
@@ -226,23 +244,58 @@ func TestAuthIntegration(t *testing.T) {
 				// Assert
 				assert.Equal(t, http.StatusNotImplemented, w.Code)
 
-				var response map[string]interface{}
+				var response errorResponse
 				err := json.Unmarshal(w.Body.Bytes(), &response)
 				require.NoError(t, err)
-				assert.Equal(t, "not implemented", response["error"])
+				assert.Equal(t, "not implemented", response.Error.Message)
 			})
 		}
 	})
 }
 
-func TestUserService_Unit(t *testing.T) {
-	// Setup test database
+func TestTransactionRoutes_RequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
 	db := SetupTestDatabase(t)
 	defer CleanupTestDatabase(t, db)
 
-	// Setup repositories and services
-	userRepo := mysql.NewUserRepo(db)
-	userService := user.NewService(userRepo)
+	router := gin.New()
+	mountRoutes(t, router, db, testLifecycle())
+
+	testCases := []struct {
+		method string
+		path   string
+	}{
+		{"GET", "/api/v1/transactions"},
+		{"POST", "/api/v1/transactions"},
+		{"GET", "/api/v1/transactions/1"},
+		{"PUT", "/api/v1/transactions/1"},
+		{"PATCH", "/api/v1/transactions/1"},
+		{"DELETE", "/api/v1/transactions/1"},
+		{"POST", "/api/v1/transactions/batch"},
+		{"GET", "/api/v1/transactions/trash"},
+		{"POST", "/api/v1/transactions/1/restore"},
+		{"GET", "/api/v1/audit"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+}
+
+func TestUserService_Unit(t *testing.T) {
+	// Setup repositories and services; no database server needed
+	memDB := memory.New()
+	userRepo := memory.NewUserRepo(memDB)
+	audits := audit.NewService(memory.NewAuditRepo(memDB), memory.NewTransactor(memDB))
+	userService := user.NewService(userRepo, audits)
 
 	ctx := context.Background()
 
@@ -0,0 +1,123 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+)
+
+const testPasetoKey = "JIusUnwiN236xiEVXMtRaJTNLyz6e0BD4U4pfX0CQNQ="
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigSources(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  address: ":8081"
+database:
+  password: "from-file"
+paseto:
+  symmetric_key: "`+testPasetoKey+`"
+`)
+
+	t.Run("Defaults fill unset keys", func(t *testing.T) {
+		cfg, err := config.Read(path)
+		require.NoError(t, err)
+		assert.Equal(t, ":8081", cfg.Server.Address)
+		assert.Equal(t, "release", cfg.Server.Mode)
+		assert.Equal(t, 3306, cfg.Database.Port)
+		assert.Equal(t, 24*60, cfg.Paseto.ExpireMinutes)
+		assert.NoError(t, cfg.Validate())
+	})
+
+	t.Run("Environment overrides every key", func(t *testing.T) {
+		t.Setenv("MMS_SERVER_ADDRESS", ":9090")
+		t.Setenv("MMS_DATABASE_PORT", "3307")
+		t.Setenv("MMS_PASSWORD_ARGON2_MEMORY_KIB", "1024")
+		t.Setenv("MMS_METRICS_ENABLED", "false")
+
+		cfg, err := config.Read(path)
+		require.NoError(t, err)
+		assert.Equal(t, ":9090", cfg.Server.Address)
+		assert.Equal(t, 3307, cfg.Database.Port)
+		assert.Equal(t, uint32(1024), cfg.Password.Argon2.MemoryKiB)
+		assert.False(t, cfg.Metrics.Enabled)
+	})
+
+	t.Run("Secrets can be read from files", func(t *testing.T) {
+		secret := filepath.Join(t.TempDir(), "db_password")
+		require.NoError(t, os.WriteFile(secret, []byte("from-secret\n"), 0o600))
+		t.Setenv("MMS_DATABASE_PASSWORD_FILE", secret)
+
+		cfg, err := config.Read(path)
+		require.NoError(t, err)
+		assert.Equal(t, "from-secret", cfg.Database.Password)
+
+		t.Setenv("MMS_DATABASE_PASSWORD", "from-env")
+		_, err = config.Read(path)
+		assert.ErrorContains(t, err, "MMS_DATABASE_PASSWORD and MMS_DATABASE_PASSWORD_FILE are both set")
+	})
+
+	t.Run("Print masks secrets", func(t *testing.T) {
+		cfg, err := config.Read(path)
+		require.NoError(t, err)
+		out, err := cfg.Print()
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `address: :8081`)
+		assert.Contains(t, string(out), config.Masked)
+		assert.NotContains(t, string(out), "from-file")
+		assert.NotContains(t, string(out), testPasetoKey)
+	})
+}
+
+func TestConfigValidationReportsEveryProblem(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  mode: "production"
+  trusted_proxies: ["10.0.0.0/8", "proxy.internal"]
+database:
+  host: ""
+paseto:
+  symmetric_key: "dG9vIHNob3J0"
+log:
+  format: "xml"
+trash:
+  retention_days: -1
+password:
+  algorithm: "bcrypt"
+  policy:
+    max_length: 100
+cors:
+  allowed_origins: ["*"]
+  allow_credentials: true
+`)
+	cfg, err := config.Read(path)
+	require.NoError(t, err)
+
+	err = cfg.Validate()
+	require.Error(t, err)
+	var joined interface{ Unwrap() []error }
+	require.True(t, errors.As(err, &joined))
+	assert.Len(t, joined.Unwrap(), 8)
+	assert.ErrorContains(t, err, "server.mode")
+	assert.ErrorContains(t, err, `server.trusted_proxies must hold IP addresses or CIDR ranges, got "proxy.internal"`)
+	assert.ErrorContains(t, err, "database.dsn")
+	assert.ErrorContains(t, err, "paseto.symmetric_key must decode to 32 bytes")
+	assert.ErrorContains(t, err, "log.format")
+	assert.ErrorContains(t, err, "trash.retention_days")
+	assert.ErrorContains(t, err, "password.policy.max_length must be at most 72 with bcrypt")
+	assert.ErrorContains(t, err, "cors.allow_credentials")
+
+	assert.ErrorContains(t, config.Load(path), "invalid config")
+}
@@ -0,0 +1,291 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/app"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/migration"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/mysql"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/postgres"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestDatabaseDriverConfig(t *testing.T) {
+	base := config.DatabaseConfig{Host: "db.internal", Port: 3306, User: "mms", Password: "pw", Name: "mms_db"}
+
+	t.Run("Defaults", func(t *testing.T) {
+		dc, err := mysql.DriverConfig(base)
+		require.NoError(t, err)
+		assert.Equal(t, "db.internal:3306", dc.Addr)
+		assert.True(t, dc.ParseTime)
+		assert.Equal(t, mysql.DefaultConnectTimeout, dc.Timeout)
+		assert.Equal(t, mysql.DefaultIOTimeout, dc.ReadTimeout)
+		assert.Nil(t, dc.TLS)
+		assert.Contains(t, dc.FormatDSN(), "charset=utf8mb4")
+	})
+
+	t.Run("Timeouts, charset and collation", func(t *testing.T) {
+		cfg := base
+		cfg.ConnectTimeoutSeconds, cfg.ReadTimeoutSeconds, cfg.WriteTimeoutSeconds = 3, 4, 5
+		cfg.Charset, cfg.Collation = "utf8mb4", "utf8mb4_unicode_ci"
+		dc, err := mysql.DriverConfig(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, 3*time.Second, dc.Timeout)
+		assert.Equal(t, 4*time.Second, dc.ReadTimeout)
+		assert.Equal(t, 5*time.Second, dc.WriteTimeout)
+		assert.Equal(t, "utf8mb4_unicode_ci", dc.Collation)
+	})
+
+	t.Run("TLS modes", func(t *testing.T) {
+		cfg := base
+		cfg.TLSMode = mysql.TLSRequired
+		dc, err := mysql.DriverConfig(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "skip-verify", dc.TLSConfig)
+
+		cfg.TLSMode = mysql.TLSVerifyIdentity
+		dc, err = mysql.DriverConfig(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, dc.TLS)
+		assert.False(t, dc.TLS.InsecureSkipVerify)
+
+		cfg.TLSMode = mysql.TLSVerifyCA
+		dc, err = mysql.DriverConfig(cfg)
+		require.NoError(t, err)
+		require.NotNil(t, dc.TLS)
+		assert.NotNil(t, dc.TLS.VerifyConnection, "the chain is still verified")
+
+		bogus := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(bogus, []byte("not a certificate"), 0o600))
+		cfg.TLSCAFile = bogus
+		_, err = mysql.DriverConfig(cfg)
+		assert.ErrorContains(t, err, "no certificates found")
+
+		cfg.TLSMode = "sometimes"
+		_, err = mysql.DriverConfig(cfg)
+		assert.Error(t, err)
+	})
+
+	t.Run("DSN is used verbatim", func(t *testing.T) {
+		dc, err := mysql.DriverConfig(config.DatabaseConfig{DSN: "u:p@tcp(other:3307)/x?tls=skip-verify", TLSMode: mysql.TLSVerifyCA})
+		require.NoError(t, err)
+		assert.Equal(t, "other:3307", dc.Addr)
+		assert.Equal(t, "skip-verify", dc.TLSConfig)
+	})
+}
+
+func TestPostgresConnString(t *testing.T) {
+	base := config.DatabaseConfig{Host: "db.internal", User: "mms", Password: "p@ss word", Name: "mms_db"}
+
+	dsn, err := postgres.ConnString(base)
+	require.NoError(t, err)
+	u, err := url.Parse(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal:5432", u.Host, "the Postgres port when none is set")
+	assert.Equal(t, "/mms_db", u.Path)
+	password, _ := u.User.Password()
+	assert.Equal(t, "p@ss word", password)
+	assert.Equal(t, "disable", u.Query().Get("sslmode"))
+	assert.Equal(t, "10", u.Query().Get("connect_timeout"))
+
+	cfg := base
+	cfg.Port, cfg.TLSMode, cfg.TLSCAFile, cfg.ConnectTimeoutSeconds = 6543, mysql.TLSVerifyCA, "/etc/ca.pem", 3
+	dsn, err = postgres.ConnString(cfg)
+	require.NoError(t, err)
+	u, err = url.Parse(dsn)
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal:6543", u.Host)
+	assert.Equal(t, "verify-ca", u.Query().Get("sslmode"))
+	assert.Equal(t, "/etc/ca.pem", u.Query().Get("sslrootcert"))
+	assert.Equal(t, "3", u.Query().Get("connect_timeout"))
+
+	cfg.TLSMode = "sometimes"
+	_, err = postgres.ConnString(cfg)
+	assert.Error(t, err)
+
+	dsn, err = postgres.ConnString(config.DatabaseConfig{DSN: "postgres://other/x", Host: "ignored"})
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://other/x", dsn)
+}
+
+func TestPostgresErrorTranslation(t *testing.T) {
+	ctx := context.Background()
+	assert.ErrorIs(t, postgres.TranslateError(ctx, &pgconn.PgError{Code: "23505"}), persistence.ErrDuplicateEntry)
+	assert.ErrorIs(t, postgres.TranslateError(ctx, &pgconn.PgError{
+		Code:    "23503",
+		Message: `insert or update on table "transactions" violates foreign key constraint "transactions_user_id_fkey"`,
+	}), persistence.ErrReferenceMissing)
+	assert.ErrorIs(t, postgres.TranslateError(ctx, &pgconn.PgError{
+		Code:    "23503",
+		Message: `update or delete on table "users" violates foreign key constraint "transactions_user_id_fkey" on table "transactions"`,
+	}), persistence.ErrStillReferenced)
+
+	other := &pgconn.PgError{Code: "42P01"}
+	assert.Same(t, other, postgres.TranslateError(ctx, other))
+	assert.ErrorIs(t, postgres.TranslateError(ctx, sql.ErrNoRows), sql.ErrNoRows)
+}
+
+func TestDatabaseConnect(t *testing.T) {
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	database := func(change func(*config.DatabaseConfig)) config.DatabaseConfig {
+		cfg := config.Get().Database
+		change(&cfg)
+		return cfg
+	}
+
+	t.Run("Applies pool settings", func(t *testing.T) {
+		cfg := database(func(db *config.DatabaseConfig) {
+			db.MaxOpenConns, db.MaxIdleConns = 7, 3
+			db.ConnectTimeoutSeconds = 2
+		})
+		db, router, err := mysql.Connect(context.Background(), cfg)
+		require.NoError(t, err)
+		defer router.Close()
+		assert.Equal(t, 7, db.Stats().MaxOpenConnections)
+	})
+
+	t.Run("Replicas connect like the primary", func(t *testing.T) {
+		cfg := database(func(db *config.DatabaseConfig) {
+			db.Replicas = []string{db.Host, fmt.Sprintf("%s:%d", db.Host, db.Port)}
+		})
+		_, router, err := mysql.Connect(context.Background(), cfg)
+		require.NoError(t, err)
+		defer router.Close()
+		router.CheckReplicas(context.Background())
+		assert.Equal(t, 2, router.HealthyReplicas(), "credentials and database come from the primary")
+
+		cfg.Replicas = []string{":3306"}
+		_, _, err = mysql.Connect(context.Background(), cfg)
+		assert.ErrorContains(t, err, "invalid database replica 0")
+	})
+
+	t.Run("Retries with backoff, then gives up", func(t *testing.T) {
+		cfg := database(func(db *config.DatabaseConfig) {
+			db.Host, db.Port = "127.0.0.1", 1
+			db.ConnectRetries, db.ConnectMaxBackoffSeconds = 2, 1
+		})
+		start := time.Now()
+		_, _, err := mysql.Connect(context.Background(), cfg)
+		assert.ErrorContains(t, err, "after 3 attempts")
+		assert.GreaterOrEqual(t, time.Since(start), 1500*time.Millisecond, "waits 0.5s, then 1s")
+	})
+
+	t.Run("Stops retrying when the context ends", func(t *testing.T) {
+		cfg := database(func(db *config.DatabaseConfig) {
+			db.Host, db.Port = "127.0.0.1", 1
+			db.ConnectRetries = 100
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, _, err := mysql.Connect(ctx, cfg)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestMySQLMigrationsCanBeRerun(t *testing.T) {
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	migrations, err := migration.Load("mysql")
+	require.NoError(t, err)
+	ctx := context.Background()
+	conn, err := helper.DB.Conn(ctx)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// MySQL DDL is not transactional, so a migration that failed half way
+	// has to be run again on a schema that already has part of it.
+	for _, m := range migrations {
+		for _, stmt := range m.Statements() {
+			_, err := conn.ExecContext(ctx, stmt)
+			require.NoError(t, err, "migration %d_%s: %s", m.Version, m.Name, stmt)
+		}
+	}
+	var chains int
+	require.NoError(t, conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_chain").Scan(&chains))
+	assert.Equal(t, 1, chains)
+}
+
+func TestSQLiteServer(t *testing.T) {
+	testServerWithDriver(t, store.DriverSQLite, `
+database:
+  driver: "sqlite"
+  path: "`+filepath.Join(t.TempDir(), "mms.db")+`"
+`)
+}
+
+func TestMemoryServer(t *testing.T) {
+	testServerWithDriver(t, store.DriverMemory, `
+database:
+  driver: "memory"
+`)
+}
+
+// testServerWithDriver boots the routes on the backend configured in
+// database and goes through register, login and a transaction round trip.
+func testServerWithDriver(t *testing.T, driver, database string) {
+	gin.SetMode(gin.TestMode)
+
+	cfg, err := config.Read(writeConfigFile(t, database+`
+paseto:
+  symmetric_key: "`+testPasetoKey+`"
+`))
+	require.NoError(t, err)
+	require.NoError(t, cfg.Validate())
+
+	repos, err := store.Open(context.Background(), cfg.Database)
+	require.NoError(t, err)
+	assert.Equal(t, driver, repos.Driver)
+	defer repos.Close()
+
+	lc := testLifecycle()
+	defer lc.Stop(t.Context())
+	router := gin.New()
+	require.NoError(t, app.Routes(router, cfg, repos, lc))
+
+	assert.Equal(t, http.StatusOK, performJSON(t, router, "GET", "/readyz", nil, nil).Code)
+
+	email := driver + "@example.com"
+	w := performJSON(t, router, "POST", "/api/v1/auth/register", request.RegisterRequest{
+		Name: "Driver User", Email: email, Password: "password123",
+	}, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{
+		Email: email, Password: "password123",
+	}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var login struct {
+		Data struct {
+			Token string `json:"access_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	auth := map[string]string{"Authorization": "Bearer " + login.Data.Token}
+
+	w = performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{
+		Amount: 42, Description: "stored in " + driver, Type: "income",
+	}, auth)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = performJSON(t, router, "GET", "/api/v1/transactions", nil, auth)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "stored in "+driver)
+}
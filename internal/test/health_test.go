@@ -0,0 +1,114 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/health"
+	"github.com/luthfiarsyad/mms/internal/interface/http/handler"
+)
+
+func TestHealthRegistry(t *testing.T) {
+	t.Run("Results are cached", func(t *testing.T) {
+		var calls atomic.Int32
+		checks := health.NewRegistry(time.Minute, time.Second)
+		checks.Register("counter", health.CheckerFunc(func(context.Context) error {
+			calls.Add(1)
+			return nil
+		}))
+
+		checks.Run(context.Background())
+		report := checks.Run(context.Background())
+
+		assert.True(t, report.OK())
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("Any failing check fails the report", func(t *testing.T) {
+		checks := health.NewRegistry(time.Millisecond, time.Second)
+		checks.Register("ok", health.CheckerFunc(func(context.Context) error { return nil }))
+		checks.Register("broken", health.CheckerFunc(func(context.Context) error { return errors.New("boom") }))
+
+		report := checks.Run(context.Background())
+
+		assert.Equal(t, health.StatusFail, report.Status)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, health.StatusOK, report.Checks[0].Status)
+		assert.Equal(t, "boom", report.Checks[1].Error)
+	})
+
+	t.Run("Slow checks time out", func(t *testing.T) {
+		checks := health.NewRegistry(time.Millisecond, 20*time.Millisecond)
+		checks.Register("slow", health.CheckerFunc(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}))
+
+		report := checks.Run(context.Background())
+
+		assert.False(t, report.OK())
+		assert.Contains(t, report.Checks[0].Error, "deadline exceeded")
+	})
+
+	t.Run("Schema version must match", func(t *testing.T) {
+		current := func(context.Context) (int, error) { return 3, nil }
+
+		assert.NoError(t, health.SchemaVersion(current, 3).Check(context.Background()))
+		assert.Error(t, health.SchemaVersion(current, 4).Check(context.Background()))
+	})
+}
+
+func TestProbeEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := SetupTestDatabase(t)
+	defer CleanupTestDatabase(t, db)
+
+	router := gin.New()
+	mountRoutes(t, router, db, testLifecycle())
+
+	t.Run("Liveness is always ok", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/livez", nil, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Readiness lists every check in verbose mode", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/readyz?verbose", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var report health.Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		assert.Equal(t, health.StatusOK, report.Status)
+
+		names := make([]string, len(report.Checks))
+		for i, c := range report.Checks {
+			names[i] = c.Name
+			assert.Equal(t, health.StatusOK, c.Status)
+		}
+		assert.Equal(t, []string{"database", "schema_version", "connection_pool"}, names)
+	})
+
+	t.Run("Readiness fails when a dependency fails", func(t *testing.T) {
+		checks := health.NewRegistry(time.Second, time.Second)
+		checks.Register("database", health.CheckerFunc(func(context.Context) error {
+			return errors.New("connection refused")
+		}))
+		h := handler.NewHealthHandler(checks, testLifecycle())
+
+		r := gin.New()
+		r.GET("/readyz", h.Readyz)
+		w := performJSON(t, r, "GET", "/readyz", nil, nil)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.JSONEq(t, `{"status":"fail"}`, w.Body.String())
+	})
+}
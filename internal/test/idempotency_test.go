@@ -0,0 +1,239 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	router, repos := newMemoryServer(t)
+	owner := registerAndLogin(t, router, "retrying@example.com")
+	other := registerAndLogin(t, router, "elsewhere@example.com")
+
+	withKey := func(auth map[string]string, key string) map[string]string {
+		return map[string]string{"Authorization": auth["Authorization"], middleware.IdempotencyKeyHeader: key}
+	}
+	count := func(auth map[string]string) int {
+		t.Helper()
+		w := performJSON(t, router, "GET", "/api/v1/transactions", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return len(page.Data)
+	}
+	lunch := request.CreateTransactionRequest{Amount: 12, Description: "lunch", Type: "expense"}
+
+	t.Run("Retries get the original response", func(t *testing.T) {
+		first := performJSON(t, router, "POST", "/api/v1/transactions", lunch, withKey(owner, "lunch-1"))
+		require.Equal(t, http.StatusCreated, first.Code, first.Body.String())
+		assert.Empty(t, first.Header().Get(middleware.ReplayedHeader))
+
+		retry := performJSON(t, router, "POST", "/api/v1/transactions", lunch, withKey(owner, "lunch-1"))
+		require.Equal(t, http.StatusCreated, retry.Code, retry.Body.String())
+		assert.Equal(t, "true", retry.Header().Get(middleware.ReplayedHeader))
+		assert.Equal(t, first.Body.String(), retry.Body.String())
+		assert.Equal(t, first.Header().Get("ETag"), retry.Header().Get("ETag"))
+		assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+		assert.Equal(t, 1, count(owner), "the retry created nothing")
+	})
+
+	t.Run("A different request with the same key is refused", func(t *testing.T) {
+		dinner := request.CreateTransactionRequest{Amount: 30, Description: "dinner", Type: "expense"}
+		w := performJSON(t, router, "POST", "/api/v1/transactions", dinner, withKey(owner, "lunch-1"))
+		require.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "IDEMPOTENCY_KEY_REUSED", resp.Error.Code)
+		assert.Equal(t, 1, count(owner))
+	})
+
+	t.Run("Keys belong to a user", func(t *testing.T) {
+		w := performJSON(t, router, "POST", "/api/v1/transactions", lunch, withKey(other, "lunch-1"))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Empty(t, w.Header().Get(middleware.ReplayedHeader))
+		assert.Equal(t, 1, count(other))
+	})
+
+	t.Run("Failed requests release their key", func(t *testing.T) {
+		invalid := map[string]any{"amount": -5, "description": "oops", "type": "expense"}
+		w := performJSON(t, router, "POST", "/api/v1/transactions", invalid, withKey(owner, "fix-me"))
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		w = performJSON(t, router, "POST", "/api/v1/transactions", lunch, withKey(owner, "fix-me"))
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		assert.Equal(t, 2, count(owner))
+	})
+
+	t.Run("Requests without a key are not deduplicated", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			require.Equal(t, http.StatusCreated, performJSON(t, router, "POST", "/api/v1/transactions", lunch, owner).Code)
+		}
+		assert.Equal(t, 4, count(owner))
+	})
+
+	t.Run("Keys must be at most 255 characters", func(t *testing.T) {
+		long := make([]byte, idempotency.MaxKeyLength+1)
+		for i := range long {
+			long[i] = 'k'
+		}
+		w := performJSON(t, router, "POST", "/api/v1/transactions", lunch, withKey(owner, string(long)))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Concurrent duplicates run once", func(t *testing.T) {
+		const clients = 8
+		var (
+			wg    sync.WaitGroup
+			codes = make([]int, clients)
+		)
+		body := request.CreateTransactionRequest{Amount: 99, Description: "double tap", Type: "expense"}
+		for i := 0; i < clients; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				codes[i] = performJSON(t, router, "POST", "/api/v1/transactions", body, withKey(other, "double-tap")).Code
+			}(i)
+		}
+		wg.Wait()
+		for _, code := range codes {
+			assert.Contains(t, []int{http.StatusCreated, http.StatusConflict}, code)
+		}
+		assert.Contains(t, codes, http.StatusCreated)
+		assert.Equal(t, 2, count(other), "one of the duplicates created a transaction")
+	})
+
+	t.Run("Stored responses expire", func(t *testing.T) {
+		purged, err := repos.Idempotency.Purge(context.Background(), time.Now().Add(25*time.Hour))
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, purged, int64(3), "keys are kept for idempotency.ttl_hours")
+
+		w := performJSON(t, router, "POST", "/api/v1/transactions", lunch, withKey(owner, "lunch-1"))
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get(middleware.ReplayedHeader), "an expired key runs the request again")
+	})
+}
+
+func TestIdempotencyService(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Keyed", Email: "keyed@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+	service := idempotency.NewService(repos.Idempotency, time.Hour, time.Minute)
+	hash := idempotency.HashRequest("POST", "/api/v1/transactions", []byte(`{"amount":1}`))
+
+	stored, err := service.Begin(ctx, u.ID, "key", hash)
+	require.NoError(t, err)
+	assert.Nil(t, stored, "the first request runs")
+
+	_, err = service.Begin(ctx, u.ID, "key", hash)
+	assert.ErrorIs(t, err, idempotency.ErrInProgress, "a retry while the first request runs")
+
+	require.NoError(t, service.Complete(ctx, u.ID, "key", http.StatusCreated, nil, []byte("created")))
+	stored, err = service.Begin(ctx, u.ID, "key", hash)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, http.StatusCreated, stored.Status)
+	assert.Equal(t, "created", string(stored.Body))
+
+	other := idempotency.HashRequest("POST", "/api/v1/transactions", []byte(`{"amount":2}`))
+	_, err = service.Begin(ctx, u.ID, "key", other)
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+	assert.NotEqual(t, hash, idempotency.HashRequest("PUT", "/api/v1/transactions", []byte(`{"amount":1}`)), "the method is part of the request")
+
+	_, err = service.Begin(ctx, u.ID, "", hash)
+	assert.ErrorIs(t, err, idempotency.ErrInvalidKey)
+
+	expiring := idempotency.NewService(repos.Idempotency, -time.Hour, time.Minute)
+	_, err = expiring.Begin(ctx, u.ID, "short-lived", hash)
+	require.NoError(t, err)
+	stored, err = expiring.Begin(ctx, u.ID, "short-lived", other)
+	require.NoError(t, err, "an expired key can be used for another request")
+	assert.Nil(t, stored)
+
+	abandoning := idempotency.NewService(repos.Idempotency, time.Hour, -time.Minute)
+	_, err = abandoning.Begin(ctx, u.ID, "lost", hash)
+	require.NoError(t, err)
+	stored, err = abandoning.Begin(ctx, u.ID, "lost", hash)
+	require.NoError(t, err, "a key held past the lock timeout is reclaimed")
+	assert.Nil(t, stored, "the retry runs")
+	stored, err = abandoning.Begin(ctx, u.ID, "key", hash)
+	require.NoError(t, err)
+	require.NotNil(t, stored, "completed keys are not reclaimed")
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Keyed", Email: "panicky@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+
+	router := gin.New()
+	router.Use(gin.Recovery(), middleware.ErrorHandler(false), func(c *gin.Context) { c.Set(middleware.ContextUserID, u.ID) })
+	router.Use(middleware.Idempotency(usecase.NewIdempotencyUsecase(idempotency.NewService(repos.Idempotency, time.Hour, time.Minute)), 16))
+	router.POST("/boom", func(c *gin.Context) { panic("handler bug") })
+	router.POST("/ok", func(c *gin.Context) { c.String(http.StatusCreated, "ok") })
+	send := func(path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("A panicking handler releases its key", func(t *testing.T) {
+		require.Equal(t, http.StatusInternalServerError, send("/boom", "panic", "{}").Code)
+		assert.Equal(t, http.StatusInternalServerError, send("/boom", "panic", "{}").Code, "the retry runs instead of getting 409")
+	})
+
+	t.Run("A response that cannot be stored releases its key", func(t *testing.T) {
+		router := gin.New()
+		router.Use(middleware.ErrorHandler(false), func(c *gin.Context) { c.Set(middleware.ContextUserID, u.ID) })
+		router.Use(middleware.Idempotency(failingComplete{idempotency.NewService(repos.Idempotency, time.Hour, time.Minute)}, 0))
+		router.POST("/ok", func(c *gin.Context) { c.String(http.StatusCreated, "ok") })
+		for i := 0; i < 2; i++ {
+			req := httptest.NewRequest("POST", "/ok", strings.NewReader("{}"))
+			req.Header.Set(middleware.IdempotencyKeyHeader, "unstored")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusCreated, w.Code, "the retry runs instead of getting 409")
+		}
+	})
+
+	t.Run("Bodies are capped", func(t *testing.T) {
+		w := send("/ok", "large", strings.Repeat("x", 17))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "BODY_TOO_LARGE", resp.Error.Code)
+		assert.Equal(t, http.StatusCreated, send("/ok", "small", strings.Repeat("x", 16)).Code)
+	})
+}
+
+// failingComplete is an idempotency store that cannot store responses.
+type failingComplete struct {
+	middleware.IdempotencyStore
+}
+
+func (failingComplete) Complete(context.Context, int64, string, int, map[string]string, []byte) error {
+	return errors.New("database is down")
+}
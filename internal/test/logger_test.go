@@ -0,0 +1,191 @@
+package test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+)
+
+func TestLogRedaction(t *testing.T) {
+	cases := []struct {
+		name, in, want string
+	}{
+		{"Email", `{"email":"john.doe@example.com"}`, `{"email":"j***@example.com"}`},
+		{"Password field", `{"new_password":"hunter2 \"quoted\""}`, `{"new_password":"[REDACTED]"}`},
+		{"PASETO token", `{"message":"issued v2.local.AbC-12_x.Zm9v"}`, `{"message":"issued v2.local.[REDACTED]"}`},
+		{"API key", `{"message":"key mms_1a2b3c4d_deadbeef"}`, `{"message":"key mms_1a2b3c4d_[REDACTED]"}`},
+		{"Bearer header", `{"header":"Bearer abc.def"}`, `{"header":"Bearer [REDACTED]"}`},
+		{"Plain text untouched", `{"message":"created 3 transactions"}`, `{"message":"created 3 transactions"}`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, logger.Redact(tc.in))
+		})
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mms.log")
+
+	other := filepath.Join(dir, "mms-access.log")
+	require.NoError(t, os.WriteFile(other, []byte("not a backup\n"), 0o644))
+
+	f, err := logger.NewRotatingFile(path, 10, 0, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "dddddddd\n", string(current))
+
+	backups, err := filepath.Glob(filepath.Join(dir, "mms-2*.log"))
+	require.NoError(t, err)
+	assert.Len(t, backups, 2, "only the newest backups are kept")
+	assert.FileExists(t, other, "files that are not backups are never pruned")
+
+	require.NoError(t, f.Rotate())
+	current, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, current)
+}
+
+func TestRotatingFileReopens(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "logs")
+	path := filepath.Join(dir, "mms.log")
+
+	f, err := logger.NewRotatingFile(path, 10, 0, 0)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("aaaaaaaa\n"))
+	require.NoError(t, err)
+
+	require.NoError(t, os.RemoveAll(dir))
+	_, err = f.Write([]byte("bbbbbbbb\n"))
+	assert.Error(t, err, "the rotated file cannot be reopened")
+
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	_, err = f.Write([]byte("cccccccc\n"))
+	require.NoError(t, err, "the next write opens the file again")
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "cccccccc\n", string(current))
+
+	require.NoError(t, f.Close())
+	_, err = f.Write([]byte("dddddddd\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestLoggerInit(t *testing.T) {
+	previous, previousLevel := logger.L, zerolog.GlobalLevel()
+	defer func() {
+		logger.L = previous
+		zerolog.SetGlobalLevel(previousLevel)
+	}()
+
+	path := filepath.Join(t.TempDir(), "mms.log")
+	require.NoError(t, logger.Init(config.LogConfig{
+		Level:    "debug",
+		Format:   logger.FormatJSON,
+		Output:   logger.OutputFile,
+		Redact:   true,
+		File:     config.LogFileConfig{Path: path},
+		Sampling: config.LogSamplingConfig{DebugBurst: 2, DebugEvery: 1000},
+	}))
+	for i := 0; i < 10; i++ {
+		logger.L.Debug().Msg("chatty")
+	}
+	logger.L.Info().Str("email", "alice@example.com").Msg("signed in")
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, `"level":"info"`, "JSON output")
+	assert.Contains(t, out, `"email":"a***@example.com"`)
+	assert.NotContains(t, out, "alice@")
+	assert.Less(t, strings.Count(out, "chatty"), 10, "debug logs are sampled")
+
+	assert.Error(t, logger.Init(config.LogConfig{Format: "xml"}))
+	assert.Error(t, logger.SetLevel("loud"))
+}
+
+func TestAdminLogLevel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	previousLevel := zerolog.GlobalLevel()
+	defer zerolog.SetGlobalLevel(previousLevel)
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+
+	previousCfg := config.Get()
+	cfg := *previousCfg
+	cfg.Admin = config.AdminConfig{Username: "ops", Password: "s3cret"}
+	config.Set(&cfg)
+	defer config.Set(previousCfg)
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+	auth := map[string]string{
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("ops:s3cret")),
+	}
+
+	t.Run("Requires basic auth", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/admin/log-level", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Changes the level at runtime", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/admin/log-level", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":{"level":"info"}}`, w.Body.String())
+
+		w = performJSON(t, router, "PUT", "/admin/log-level", map[string]string{"level": "debug"}, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":{"level":"debug"}}`, w.Body.String())
+		assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+	})
+
+	t.Run("Rejects unknown levels", func(t *testing.T) {
+		w := performJSON(t, router, "PUT", "/admin/log-level", map[string]string{"level": "loud"}, auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"code":"VALIDATION_FAILED"`)
+		assert.Contains(t, w.Body.String(), `"field":"level"`)
+		assert.Equal(t, zerolog.DebugLevel, zerolog.GlobalLevel())
+	})
+}
+
+func TestAdminIsRateLimited(t *testing.T) {
+	router, _ := newMemoryServerWithConfig(t, `
+admin:
+  username: "ops"
+  password: "s3cret"
+rate_limit:
+  enabled: true
+  requests_per_second: 0.001
+  burst: 2
+`)
+	guess := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("ops:guess"))}
+	for range 2 {
+		assert.Equal(t, http.StatusUnauthorized, performJSON(t, router, "GET", "/admin/log-level", nil, guess).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, performJSON(t, router, "GET", "/admin/log-level", nil, guess).Code, "password guesses are limited")
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	previousCfg := config.Get()
+	cfg := *previousCfg
+	cfg.Metrics = config.MetricsConfig{Enabled: true, Username: "prom", Password: "scrape"}
+	config.Set(&cfg)
+	defer config.Set(previousCfg)
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+
+	userID := helper.CreateTestUser("Metrics User", "metrics@example.com", "unused")
+	bearer := map[string]string{"Authorization": "Bearer " + helper.CreateTestToken(userID)}
+
+	w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{
+		Amount: 12, Description: "book", Type: "expense",
+	}, bearer)
+	require.Equal(t, http.StatusCreated, w.Code)
+	performJSON(t, router, "GET", "/api/v1/transactions/999999", nil, bearer)
+	performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{
+		Email: "metrics@example.com", Password: "wrong-password",
+	}, nil)
+
+	scrape := func(user, pass string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		if user != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("Requires basic auth when configured", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, scrape("", "").Code)
+		assert.Equal(t, http.StatusUnauthorized, scrape("prom", "guess").Code)
+	})
+
+	t.Run("Exposes HTTP, pool and domain metrics", func(t *testing.T) {
+		w := scrape("prom", "scrape")
+		require.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+
+		assert.Contains(t, body, `mms_http_requests_total{method="POST",route="/api/v1/transactions",status="201"}`)
+		assert.Contains(t, body, `mms_http_requests_total{method="GET",route="/api/v1/transactions/:id",status="404"}`)
+		assert.NotContains(t, body, "/transactions/999999", "raw paths are never used as labels")
+		assert.Contains(t, body, `mms_http_request_duration_seconds_bucket{method="POST",route="/api/v1/transactions"`)
+		assert.Contains(t, body, `go_sql_open_connections{db_name="mms"}`)
+		assert.Contains(t, body, `mms_transactions_created_total{type="expense"}`)
+		assert.Contains(t, body, `mms_logins_total{result="failure"}`)
+		assert.Contains(t, body, "mms_registrations_total")
+	})
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/app"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestPasswordHasher_Unit(t *testing.T) {
+	// Keep argon2 cheap so the test stays fast
+	cfg := config.PasswordConfig{
+		Algorithm: "argon2id",
+		Argon2: config.Argon2Config{
+			MemoryKiB:   1024,
+			Iterations:  1,
+			Parallelism: 1,
+		},
+	}
+	hasher, err := security.NewPasswordHasher(cfg)
+	require.NoError(t, err)
+
+	t.Run("Argon2id hash round trip", func(t *testing.T) {
+		encoded, err := hasher.Hash("s3cret-password")
+		require.NoError(t, err)
+
+		assert.Contains(t, encoded, "$argon2id$v=19$m=1024,t=1,p=1$")
+		assert.NoError(t, hasher.Verify(encoded, "s3cret-password"))
+		assert.ErrorIs(t, hasher.Verify(encoded, "wrong-password"), security.ErrPasswordMismatch)
+		assert.False(t, hasher.NeedsRehash(encoded))
+	})
+
+	t.Run("Changed argon2 parameters need rehash", func(t *testing.T) {
+		encoded, err := hasher.Hash("s3cret-password")
+		require.NoError(t, err)
+
+		stronger := cfg
+		stronger.Argon2.Iterations = 2
+		upgraded, err := security.NewPasswordHasher(stronger)
+		require.NoError(t, err)
+
+		assert.NoError(t, upgraded.Verify(encoded, "s3cret-password"))
+		assert.True(t, upgraded.NeedsRehash(encoded))
+	})
+
+	t.Run("Legacy bcrypt hash verifies and needs rehash", func(t *testing.T) {
+		legacy, err := bcrypt.GenerateFromPassword([]byte("s3cret-password"), bcrypt.MinCost)
+		require.NoError(t, err)
+
+		assert.NoError(t, hasher.Verify(string(legacy), "s3cret-password"))
+		assert.ErrorIs(t, hasher.Verify(string(legacy), "wrong-password"), security.ErrPasswordMismatch)
+		assert.True(t, hasher.NeedsRehash(string(legacy)))
+	})
+
+	t.Run("Unknown hash format is rejected", func(t *testing.T) {
+		assert.ErrorIs(t, hasher.Verify("plaintext", "plaintext"), security.ErrUnsupportedHash)
+		assert.True(t, hasher.NeedsRehash("plaintext"))
+	})
+
+	t.Run("Out of range argon2 parameters are rejected", func(t *testing.T) {
+		encoded, err := hasher.Hash("s3cret-password")
+		require.NoError(t, err)
+		for _, params := range []string{"m=1024,t=0,p=1", "m=1024,t=1,p=0", "m=1024,t=1000,p=1", "m=99999999,t=1,p=1"} {
+			forged := strings.Replace(encoded, "m=1024,t=1,p=1", params, 1)
+			assert.ErrorIs(t, hasher.Verify(forged, "s3cret-password"), security.ErrUnsupportedHash, params)
+		}
+		parts := strings.Split(encoded, "$")
+		parts[5] = ""
+		assert.ErrorIs(t, hasher.Verify(strings.Join(parts, "$"), "anything"), security.ErrUnsupportedHash, "an empty key matches every password")
+	})
+
+	t.Run("Unknown algorithm fails construction", func(t *testing.T) {
+		_, err := security.NewPasswordHasher(config.PasswordConfig{Algorithm: "md5"})
+		assert.Error(t, err)
+	})
+}
+
+func TestLoginRehashesPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg, err := config.Read(writeConfigFile(t, `
+database:
+  driver: "memory"
+password:
+  algorithm: "argon2id"
+  argon2:
+    memory_kib: 1024
+    iterations: 1
+    parallelism: 1
+paseto:
+  symmetric_key: "`+testPasetoKey+`"
+`))
+	require.NoError(t, err)
+	repos := store.NewMemory()
+	lc := testLifecycle()
+	defer lc.Stop(context.Background())
+	router := gin.New()
+	require.NoError(t, app.Routes(router, cfg, repos, lc))
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("s3cret-password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	outdated, err := security.NewArgon2idHasher(security.Argon2idParams{Memory: 1024, Iterations: 2, Parallelism: 1}).Hash("s3cret-password")
+	require.NoError(t, err)
+
+	for name, stored := range map[string]string{"bcrypt": string(legacy), "old argon2id parameters": outdated} {
+		t.Run(name, func(t *testing.T) {
+			email := strings.ReplaceAll(name, " ", "-") + "@example.com"
+			require.NoError(t, repos.Users.Create(context.Background(), &user.User{Name: "Legacy", Email: email, Password: stored}))
+
+			w := performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{Email: email, Password: "s3cret-password"}, nil)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			u, err := repos.Users.FindByEmail(context.Background(), email)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(u.Password, "$argon2id$v=19$m=1024,t=1,p=1$"), "the stored hash is rewritten, got %s", u.Password)
+
+			w = performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{Email: email, Password: "s3cret-password"}, nil)
+			assert.Equal(t, http.StatusOK, w.Code, "the new hash verifies")
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func violationCodes(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *security.PolicyError
+	require.ErrorAs(t, err, &policyErr)
+	codes := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		codes[i] = v.Code
+	}
+	return codes
+}
+
+func TestPasswordPolicy_Unit(t *testing.T) {
+	cfg := config.PasswordPolicyConfig{
+		MinLength:            10,
+		MaxLength:            20,
+		RequireUpper:         true,
+		RequireLower:         true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		DisallowPersonalInfo: true,
+	}
+
+	t.Run("Strong password passes", func(t *testing.T) {
+		policy, err := security.NewPasswordPolicy(config.PasswordConfig{Policy: cfg})
+		require.NoError(t, err)
+
+		assert.NoError(t, policy.Validate("Tr0ub4dor&3x", "jane@example.com", "Jane Doe"))
+	})
+
+	t.Run("All violations are reported at once", func(t *testing.T) {
+		policy, err := security.NewPasswordPolicy(config.PasswordConfig{Policy: cfg})
+		require.NoError(t, err)
+
+		codes := violationCodes(t, policy.Validate("short"))
+		assert.ElementsMatch(t, []string{
+			security.ViolationTooShort,
+			security.ViolationMissingUpper,
+			security.ViolationMissingDigit,
+			security.ViolationMissingSymbol,
+		}, codes)
+
+		codes = violationCodes(t, policy.Validate(strings.Repeat("Aa1!", 6)))
+		assert.Equal(t, []string{security.ViolationTooLong}, codes)
+	})
+
+	t.Run("Password containing email or name is rejected", func(t *testing.T) {
+		policy, err := security.NewPasswordPolicy(config.PasswordConfig{Policy: cfg})
+		require.NoError(t, err)
+
+		codes := violationCodes(t, policy.Validate("Janedoe#2024", "janedoe@example.com", "Jane Doe"))
+		assert.Equal(t, []string{security.ViolationPersonalInfo}, codes)
+	})
+
+	t.Run("Breached password from local list is rejected", func(t *testing.T) {
+		sum := sha1.Sum([]byte("P@ssw0rd1234"))
+		list := "# sample breached list\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n"
+		path := filepath.Join(t.TempDir(), "breached.txt")
+		require.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+
+		withList := cfg
+		withList.BreachedListFile = path
+		policy, err := security.NewPasswordPolicy(config.PasswordConfig{Policy: withList})
+		require.NoError(t, err)
+
+		codes := violationCodes(t, policy.Validate("P@ssw0rd1234"))
+		assert.Equal(t, []string{security.ViolationBreached}, codes)
+		assert.NoError(t, policy.Validate("Tr0ub4dor&3x"))
+	})
+
+	t.Run("Invalid configuration fails construction", func(t *testing.T) {
+		_, err := security.NewPasswordPolicy(config.PasswordConfig{Policy: config.PasswordPolicyConfig{MinLength: 30, MaxLength: 10}})
+		assert.Error(t, err)
+
+		_, err = security.NewPasswordPolicy(config.PasswordConfig{Policy: config.PasswordPolicyConfig{BreachedListFile: "does-not-exist.txt"}})
+		assert.Error(t, err)
+
+		_, err = security.NewPasswordPolicy(config.PasswordConfig{Algorithm: "bcrypt", Policy: config.PasswordPolicyConfig{MaxLength: 100}})
+		assert.Error(t, err, "bcrypt takes at most 72 bytes")
+
+		path := filepath.Join(t.TempDir(), "plain.txt")
+		require.NoError(t, os.WriteFile(path, []byte("password123\n"), 0o600))
+		_, err = security.NewPasswordPolicy(config.PasswordConfig{Policy: config.PasswordPolicyConfig{BreachedListFile: path}})
+		assert.ErrorContains(t, err, "invalid SHA-1 digest")
+	})
+
+	t.Run("Bcrypt caps passwords at 72 bytes", func(t *testing.T) {
+		policy, err := security.NewPasswordPolicy(config.PasswordConfig{Algorithm: "bcrypt"})
+		require.NoError(t, err)
+
+		assert.NoError(t, policy.Validate(strings.Repeat("a1", 36)))
+		assert.Equal(t, []string{security.ViolationTooLong}, violationCodes(t, policy.Validate(strings.Repeat("a1", 36)+"b")))
+		assert.Equal(t, []string{security.ViolationTooLong}, violationCodes(t, policy.Validate(strings.Repeat("é1", 30))), "72 bytes, not characters")
+
+		policy, err = security.NewPasswordPolicy(config.PasswordConfig{Algorithm: "argon2id"})
+		require.NoError(t, err)
+		assert.NoError(t, policy.Validate(strings.Repeat("a1", 50)))
+	})
+}
+
+func TestBreachedPasswordList(t *testing.T) {
+	digest := func(password string) string {
+		sum := sha1.Sum([]byte(password))
+		return strings.ToUpper(hex.EncodeToString(sum[:]))
+	}
+	var lines []string
+	for i := range 500 {
+		lines = append(lines, fmt.Sprintf("%s:%d", digest(fmt.Sprintf("breached-%d", i)), i+1))
+	}
+	slices.Sort(lines)
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# sorted by hash\n\n"+strings.Join(lines, "\r\n")), 0o600))
+
+	list, err := security.OpenBreachedPasswordList(path)
+	require.NoError(t, err)
+	defer list.Close()
+	for i := range 500 {
+		found, err := list.Contains(fmt.Sprintf("breached-%d", i))
+		require.NoError(t, err)
+		assert.True(t, found, "breached-%d", i)
+
+		found, err = list.Contains(fmt.Sprintf("fine-%d", i))
+		require.NoError(t, err)
+		assert.False(t, found, "fine-%d", i)
+	}
+}
+
+func TestAdminPasswordReset(t *testing.T) {
+	router, repos := newMemoryServerWithConfig(t, `
+admin:
+  username: "ops"
+  password: "s3cret"
+`)
+	registerAndLogin(t, router, "forgetful@example.com")
+	u, err := repos.Users.FindByEmail(context.Background(), "forgetful@example.com")
+	require.NoError(t, err)
+
+	ops := map[string]string{"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte("ops:s3cret"))}
+	path := fmt.Sprintf("/admin/users/%d/password", u.ID)
+
+	w := performJSON(t, router, "PUT", path, request.ResetPasswordRequest{NewPassword: "forgetful1"}, ops)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), security.ViolationPersonalInfo, "the password policy applies")
+	assert.Equal(t, http.StatusUnauthorized, performJSON(t, router, "PUT", path, request.ResetPasswordRequest{NewPassword: "n3w-passphrase"}, nil).Code)
+	assert.Equal(t, http.StatusNotFound, performJSON(t, router, "PUT", "/admin/users/999999/password", request.ResetPasswordRequest{NewPassword: "n3w-passphrase"}, ops).Code)
+
+	w = performJSON(t, router, "PUT", path, request.ResetPasswordRequest{NewPassword: "n3w-passphrase"}, ops)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	active, err := repos.Sessions.FindActiveByUserID(context.Background(), u.ID, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, active, "every session is revoked")
+
+	w = performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{Email: u.Email, Password: "password123"}, nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{Email: u.Email, Password: "n3w-passphrase"}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
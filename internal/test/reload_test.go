@@ -0,0 +1,203 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+const reloadBaseConfig = `
+server:
+  address: ":8080"
+paseto:
+  symmetric_key: "` + testPasetoKey + `"
+log:
+  level: "info"
+`
+
+func TestConfigReload(t *testing.T) {
+	previous := config.Get()
+	defer config.Set(previous)
+
+	path := writeConfigFile(t, reloadBaseConfig)
+	require.NoError(t, config.Load(path))
+
+	var origins [][]string
+	cancel := config.OnChange(func(c *config.Config) config.CORSConfig { return c.CORS }, func(cors config.CORSConfig) {
+		origins = append(origins, cors.AllowedOrigins)
+	})
+	defer cancel()
+
+	t.Run("Applies reloadable keys and rejects the rest", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(`
+server:
+  address: ":9999"
+paseto:
+  symmetric_key: "`+testPasetoKey+`"
+  expire_minutes: 30
+log:
+  level: "debug"
+cors:
+  allowed_origins: ["https://app.example.com"]
+`), 0o600))
+
+		rejected, err := config.Reload()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"server.address"}, rejected)
+
+		cfg := config.Get()
+		assert.Equal(t, ":8080", cfg.Server.Address, "listen address needs a restart")
+		assert.Equal(t, "debug", cfg.Log.Level)
+		assert.Equal(t, 30, cfg.Paseto.ExpireMinutes)
+		assert.Equal(t, [][]string{{"https://app.example.com"}}, origins)
+	})
+
+	t.Run("Keeps the running config when the file is invalid", func(t *testing.T) {
+		before := config.Get()
+		require.NoError(t, os.WriteFile(path, []byte(reloadBaseConfig+`
+rate_limit:
+  enabled: true
+  burst: 0
+`), 0o600))
+
+		_, err := config.Reload()
+		assert.ErrorContains(t, err, "rate_limit.burst")
+		assert.Same(t, before, config.Get())
+	})
+
+	t.Run("Watches the file", func(t *testing.T) {
+		require.NoError(t, config.Watch(func([]string, error) {}))
+		require.NoError(t, os.WriteFile(path, []byte(reloadBaseConfig+`
+cors:
+  allowed_origins: ["https://watched.example.com"]
+`), 0o600))
+
+		assert.Eventually(t, func() bool {
+			return len(config.Get().CORS.AllowedOrigins) == 1 &&
+				config.Get().CORS.AllowedOrigins[0] == "https://watched.example.com"
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	cancel()
+	config.Set(previous)
+	assert.Len(t, origins, 2, "cancelled subscribers are not called")
+}
+
+func TestReloadableMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	previous := config.Get()
+	defer config.Set(previous)
+	cfg := *previous
+	cfg.CORS = config.CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAgeSeconds:  600,
+	}
+	cfg.RateLimit = config.RateLimitConfig{Enabled: true, RequestsPerSecond: 0.001, Burst: 2}
+	config.Set(&cfg)
+
+	lc := testLifecycle()
+	defer lc.Stop(t.Context())
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, lc)
+
+	update := func(change func(*config.Config)) {
+		next := *config.Get()
+		change(&next)
+		config.Set(&next)
+	}
+
+	t.Run("CORS preflight", func(t *testing.T) {
+		preflight := map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": "POST",
+		}
+		w := performJSON(t, router, "OPTIONS", "/api/v1/transactions", nil, preflight)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+		preflight["Origin"] = "https://new.example.com"
+		w = performJSON(t, router, "OPTIONS", "/api/v1/transactions", nil, preflight)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		update(func(c *config.Config) { c.CORS.AllowedOrigins = []string{"https://new.example.com"} })
+		w = performJSON(t, router, "OPTIONS", "/api/v1/transactions", nil, preflight)
+		assert.Equal(t, "https://new.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("CORS credentials need a listed origin", func(t *testing.T) {
+		update(func(c *config.Config) {
+			c.CORS.AllowedOrigins = []string{"*", "https://app.example.com"}
+			c.CORS.AllowCredentials = true
+		})
+		defer update(func(c *config.Config) { c.CORS.AllowCredentials = false })
+
+		listed := map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"}
+		w := performJSON(t, router, "OPTIONS", "/api/v1/transactions", nil, listed)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+		other := map[string]string{"Origin": "https://evil.example.com", "Access-Control-Request-Method": "GET"}
+		w = performJSON(t, router, "OPTIONS", "/api/v1/transactions", nil, other)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("Rate limit", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			w := performJSON(t, router, "GET", "/api/v1/transactions", nil, nil)
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		}
+		w := performJSON(t, router, "GET", "/api/v1/transactions", nil, nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+		forged := map[string]string{"X-Forwarded-For": "203.0.113.7"}
+		assert.Equal(t, http.StatusTooManyRequests, performJSON(t, router, "GET", "/api/v1/transactions", nil, forged).Code,
+			"a forged X-Forwarded-For does not get a fresh bucket")
+		assert.Equal(t, http.StatusOK, performJSON(t, router, "GET", "/livez", nil, nil).Code, "probes are not limited")
+
+		update(func(c *config.Config) { c.RateLimit.RequestsPerSecond = 1000 })
+		assert.Eventually(t, func() bool {
+			return performJSON(t, router, "GET", "/api/v1/transactions", nil, nil).Code == http.StatusUnauthorized
+		}, time.Second, 10*time.Millisecond)
+
+		update(func(c *config.Config) { c.RateLimit.Enabled = false })
+	})
+
+	t.Run("Token TTL", func(t *testing.T) {
+		w := performJSON(t, router, "POST", "/api/v1/auth/register", request.RegisterRequest{
+			Name: "Reload User", Email: "reload@example.com", Password: "password123",
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		update(func(c *config.Config) { c.Paseto.ExpireMinutes = 30 })
+		w = performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{
+			Email: "reload@example.com", Password: "password123",
+		}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body struct {
+			Data struct {
+				ExpiresIn int `json:"expires_in"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 1800, body.Data.ExpiresIn)
+	})
+}
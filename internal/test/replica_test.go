@@ -0,0 +1,104 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/mysql"
+	"github.com/luthfiarsyad/mms/pkg/actor"
+)
+
+// setupReplicaDatabase stands in for a lagging replica: a second schema with
+// a transactions table but its own rows.
+func setupReplicaDatabase(t *testing.T) *sql.DB {
+	tc := GetTestConfig()
+	name := tc.DatabaseName + "_replica"
+	server, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/", tc.DatabaseUser, tc.DatabasePassword, tc.DatabaseHost, tc.DatabasePort))
+	require.NoError(t, err)
+	defer server.Close()
+	_, err = server.Exec("DROP DATABASE IF EXISTS " + name)
+	require.NoError(t, err)
+	_, err = server.Exec("CREATE DATABASE " + name)
+	require.NoError(t, err)
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&loc=Local",
+		tc.DatabaseUser, tc.DatabasePassword, tc.DatabaseHost, tc.DatabasePort, name))
+	require.NoError(t, err)
+	// Only the table the transaction repository reads is needed.
+	_, err = db.Exec(`CREATE TABLE transactions (
+		id BIGINT AUTO_INCREMENT PRIMARY KEY,
+		user_id BIGINT NOT NULL,
+		amount DECIMAL(10,2) NOT NULL,
+		description VARCHAR(500) NOT NULL,
+		type ENUM('income', 'expense') NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		deleted_at TIMESTAMP NULL DEFAULT NULL,
+		version BIGINT NOT NULL DEFAULT 1
+	)`)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestReadReplicaRouting(t *testing.T) {
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+	replicaDB := setupReplicaDatabase(t)
+
+	userID := helper.CreateTestUser("Replica User", "replica@example.com", "hashed")
+	_, err := helper.DB.Exec("INSERT INTO transactions (user_id, amount, description, type) VALUES (?, 10, 'primary', 'income')", userID)
+	require.NoError(t, err)
+	_, err = replicaDB.Exec("INSERT INTO transactions (user_id, amount, description, type) VALUES (?, 10, 'replica', 'income')", userID)
+	require.NoError(t, err)
+
+	const sticky = 200 * time.Millisecond
+	router := mysql.NewRouter(helper.DB, []*sql.DB{replicaDB}, sticky)
+	repo := mysql.NewRoutedTxRepo(router)
+	ctx := actor.NewContext(context.Background(), userID)
+
+	readFrom := func() string {
+		t.Helper()
+		txs, err := repo.FindByUserID(ctx, userID, transaction.Page{})
+		require.NoError(t, err)
+		require.NotEmpty(t, txs)
+		return txs[len(txs)-1].Description
+	}
+
+	t.Run("Replicas take reads once healthy", func(t *testing.T) {
+		assert.Equal(t, "primary", readFrom(), "unchecked replicas are not used")
+
+		router.CheckReplicas(context.Background())
+		assert.Equal(t, 1, router.HealthyReplicas())
+		assert.Equal(t, "replica", readFrom())
+	})
+
+	t.Run("Reads stay on the primary after a write", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, repo.Create(ctx, &transaction.Transaction{
+			UserID: userID, Amount: 5, Description: "new", Type: string(transaction.TransactionTypeExpense), CreatedAt: now.Add(time.Hour), UpdatedAt: now,
+		}))
+		assert.Equal(t, "primary", readFrom(), "the user sees their own write")
+
+		other := actor.NewContext(context.Background(), userID+1)
+		txs, err := repo.FindByUserID(other, userID, transaction.Page{})
+		require.NoError(t, err)
+		assert.Equal(t, "replica", txs[len(txs)-1].Description, "other users are not pinned")
+
+		assert.Eventually(t, func() bool { return readFrom() == "replica" }, 2*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("Falls back to the primary when replicas fail", func(t *testing.T) {
+		require.NoError(t, replicaDB.Close())
+		router.CheckReplicas(context.Background())
+		assert.Equal(t, 0, router.HealthyReplicas())
+		assert.Equal(t, "primary", readFrom())
+	})
+}
@@ -0,0 +1,76 @@
+package test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/pkg/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	var logs bytes.Buffer
+	previous := logger.L
+	logger.L = zerolog.New(&logs).Level(zerolog.DebugLevel)
+	defer func() { logger.L = previous }()
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+
+	userID := helper.CreateTestUser("Traced User", "traced@example.com", "unused")
+	bearer := map[string]string{"Authorization": "Bearer " + helper.CreateTestToken(userID)}
+
+	t.Run("Client IDs are echoed", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/livez", nil, map[string]string{requestid.Header: "client-id-42"})
+		assert.Equal(t, "client-id-42", w.Header().Get(requestid.Header))
+	})
+
+	t.Run("Missing or unsafe IDs are replaced", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/livez", nil, nil)
+		assert.Len(t, w.Header().Get(requestid.Header), 32)
+
+		w = performJSON(t, router, "GET", "/livez", nil, map[string]string{requestid.Header: "bad id\n"})
+		assert.NotEqual(t, "bad id\n", w.Header().Get(requestid.Header))
+		assert.True(t, requestid.Valid(w.Header().Get(requestid.Header)))
+	})
+
+	t.Run("Usecase logs carry request, user and route", func(t *testing.T) {
+		logs.Reset()
+		headers := map[string]string{requestid.Header: "trace-me", "Authorization": bearer["Authorization"]}
+		w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{
+			Amount: 3, Description: "tea", Type: "expense",
+		}, headers)
+		require.Equal(t, http.StatusCreated, w.Code)
+
+		var found bool
+		scanner := bufio.NewScanner(&logs)
+		for scanner.Scan() {
+			var line map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			msg, _ := line["message"].(string)
+			if !strings.HasPrefix(msg, "TransactionUsecase.CreateTransaction") {
+				continue
+			}
+			found = true
+			assert.Equal(t, "trace-me", line["request_id"])
+			assert.Equal(t, float64(userID), line["user_id"])
+			assert.Equal(t, "/api/v1/transactions", line["route"])
+		}
+		assert.True(t, found, "expected usecase log lines")
+	})
+}
@@ -0,0 +1,158 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/middleware"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/interface/http/response"
+)
+
+type fieldErrorResponse struct {
+	Error struct {
+		Code    string                `json:"code"`
+		Message string                `json:"message"`
+		Details []response.FieldError `json:"details"`
+	} `json:"error"`
+}
+
+func TestValidationErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.ErrorHandler(false))
+	router.POST("/transactions", func(c *gin.Context) {
+		var req request.CreateTransactionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(response.BindError(err))
+			return
+		}
+		response.Created(c, req)
+	})
+
+	post := func(body any, lang string) fieldErrorResponse {
+		w := performJSON(t, router, "POST", "/transactions", body, map[string]string{"Accept-Language": lang})
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var resp fieldErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	invalid := map[string]any{"amount": -5, "type": "gift"}
+
+	t.Run("Fields are named after json tags", func(t *testing.T) {
+		resp := post(invalid, "")
+
+		assert.Equal(t, "VALIDATION_FAILED", resp.Error.Code)
+		assert.Equal(t, "request validation failed", resp.Error.Message)
+		assert.ElementsMatch(t, []response.FieldError{
+			{Field: "amount", Rule: "gt", Message: "amount must be greater than 0"},
+			{Field: "description", Rule: "required", Message: "description is required"},
+			{Field: "type", Rule: "oneof", Message: "type must be one of: income, expense"},
+		}, resp.Error.Details)
+	})
+
+	t.Run("Messages follow Accept-Language", func(t *testing.T) {
+		resp := post(invalid, "id-ID,id;q=0.9,en;q=0.8")
+
+		assert.Equal(t, "validasi permintaan gagal", resp.Error.Message)
+		require.Len(t, resp.Error.Details, 3)
+		assert.Contains(t, resp.Error.Details, response.FieldError{
+			Field: "description", Rule: "required", Message: "description wajib diisi",
+		})
+	})
+
+	t.Run("Unsupported languages fall back to English", func(t *testing.T) {
+		resp := post(invalid, "fr")
+		assert.Equal(t, "request validation failed", resp.Error.Message)
+	})
+
+	t.Run("Wrong JSON types are reported per field", func(t *testing.T) {
+		resp := post(map[string]any{"amount": "ten", "description": "x", "type": "income"}, "en")
+
+		require.Len(t, resp.Error.Details, 1)
+		assert.Equal(t, response.FieldError{
+			Field: "amount", Rule: "type", Message: "amount must be a number",
+		}, resp.Error.Details[0])
+	})
+
+	t.Run("Malformed JSON is an invalid request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/transactions", strings.NewReader(`{"amount":`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "INVALID_REQUEST", resp.Error.Code)
+	})
+}
+
+func TestTransactionListPagination(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	userID := helper.CreateTestUser("Paging User", "paging@example.com", "unused")
+	bearer := map[string]string{"Authorization": "Bearer " + helper.CreateTestToken(userID)}
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+
+	for i := 1; i <= 5; i++ {
+		w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{
+			Amount: float64(i), Description: fmt.Sprintf("item %d", i), Type: "expense",
+		}, bearer)
+		require.Equal(t, http.StatusCreated, w.Code)
+	}
+
+	type page struct {
+		Data []map[string]any `json:"data"`
+		Meta response.Meta    `json:"meta"`
+	}
+	list := func(query string) page {
+		w := performJSON(t, router, "GET", "/api/v1/transactions"+query, nil, bearer)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp page
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("Defaults to the first page", func(t *testing.T) {
+		resp := list("")
+		assert.Len(t, resp.Data, 5)
+		assert.Equal(t, response.Meta{Page: 1, PerPage: response.DefaultPerPage, Total: 5, TotalPages: 1}, resp.Meta)
+	})
+
+	t.Run("Returns the requested page", func(t *testing.T) {
+		resp := list("?page=3&per_page=2")
+		assert.Len(t, resp.Data, 1)
+		assert.Equal(t, response.Meta{Page: 3, PerPage: 2, Total: 5, TotalPages: 3}, resp.Meta)
+	})
+
+	t.Run("Pages past the end are empty", func(t *testing.T) {
+		resp := list("?page=9&per_page=2")
+		assert.NotNil(t, resp.Data)
+		assert.Empty(t, resp.Data)
+	})
+
+	t.Run("Invalid query parameters are rejected", func(t *testing.T) {
+		w := performJSON(t, router, "GET", "/api/v1/transactions?per_page=1000", nil, bearer)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp fieldErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		require.Len(t, resp.Error.Details, 1)
+		assert.Equal(t, "per_page", resp.Error.Details[0].Field)
+	})
+}
@@ -0,0 +1,149 @@
+package test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestSessionIntegration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	helper.CreateTestUser("Session User", "sessions@example.com", string(hashed))
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+
+	login := func(userAgent string) string {
+		w := performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{
+			Email:    "sessions@example.com",
+			Password: "password123",
+		}, map[string]string{"User-Agent": userAgent})
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var resp struct {
+			Data struct {
+				AccessToken string `json:"access_token"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data.AccessToken
+	}
+	laptop := map[string]string{"Authorization": "Bearer " + login("laptop-browser")}
+	phone := map[string]string{"Authorization": "Bearer " + login("phone-app")}
+
+	type sessionList struct {
+		Sessions []struct {
+			ID        string `json:"id"`
+			UserAgent string `json:"user_agent"`
+			Current   bool   `json:"current"`
+		} `json:"data"`
+	}
+	list := func(headers map[string]string) sessionList {
+		w := performJSON(t, router, "GET", "/api/v1/me/sessions", nil, headers)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp sessionList
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("Each login is listed as a session", func(t *testing.T) {
+		resp := list(laptop)
+		require.Len(t, resp.Sessions, 2)
+
+		current := 0
+		for _, s := range resp.Sessions {
+			if s.Current {
+				current++
+				assert.Equal(t, "laptop-browser", s.UserAgent)
+			}
+		}
+		assert.Equal(t, 1, current)
+	})
+
+	t.Run("Revoked session can no longer authenticate", func(t *testing.T) {
+		var phoneID string
+		for _, s := range list(laptop).Sessions {
+			if s.UserAgent == "phone-app" {
+				phoneID = s.ID
+			}
+		}
+		require.NotEmpty(t, phoneID)
+
+		w := performJSON(t, router, "DELETE", "/api/v1/me/sessions/"+phoneID, nil, laptop)
+		require.Equal(t, http.StatusNoContent, w.Code)
+
+		w = performJSON(t, router, "GET", "/api/v1/me/sessions", nil, phone)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Len(t, list(laptop).Sessions, 1)
+	})
+
+	t.Run("Unknown session cannot be revoked", func(t *testing.T) {
+		w := performJSON(t, router, "DELETE", "/api/v1/me/sessions/does-not-exist", nil, laptop)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSessionClientIP(t *testing.T) {
+	// loginFrom logs in over a connection from 192.0.2.1 that claims, in
+	// X-Forwarded-For, to be relaying for 203.0.113.9, and returns the IP
+	// recorded for the session.
+	loginFrom := func(t *testing.T, router http.Handler, email string) string {
+		t.Helper()
+		w := performJSON(t, router, "POST", "/api/v1/auth/register", request.RegisterRequest{
+			Name: "Proxied User", Email: email, Password: "password123",
+		}, nil)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"email":"`+email+`","password":"password123"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", "203.0.113.9")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var login struct {
+			Data struct {
+				AccessToken string `json:"access_token"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+		w = performJSON(t, router, "GET", "/api/v1/me/sessions", nil, map[string]string{"Authorization": "Bearer " + login.Data.AccessToken})
+		require.Equal(t, http.StatusOK, w.Code)
+		var list struct {
+			Data []struct {
+				IP string `json:"ip"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.Len(t, list.Data, 1)
+		return list.Data[0].IP
+	}
+
+	t.Run("A forged X-Forwarded-For is ignored", func(t *testing.T) {
+		router, _ := newMemoryServer(t)
+		assert.Equal(t, "192.0.2.1", loginFrom(t, router, "direct@example.com"))
+	})
+
+	t.Run("A trusted proxy reports the client", func(t *testing.T) {
+		router, _ := newMemoryServerWithConfig(t, `
+server:
+  trusted_proxies: ["192.0.2.0/24"]
+`)
+		assert.Equal(t, "203.0.113.9", loginFrom(t, router, "proxied@example.com"))
+	})
+}
@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/http/server"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
+)
+
+func TestGracefulShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lc := lifecycle.New(context.Background())
+
+	var (
+		mu    sync.Mutex
+		steps []string
+	)
+	record := func(step string) {
+		mu.Lock()
+		defer mu.Unlock()
+		steps = append(steps, step)
+	}
+
+	lc.Go("ticker", func(ctx context.Context) {
+		<-ctx.Done()
+		record("worker stopped")
+	})
+	lc.OnClose("database", func() error {
+		record("database closed")
+		return nil
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := gin.New()
+	router.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		record("request finished")
+		c.String(http.StatusOK, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	base := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := server.New(config.ServerConfig{ShutdownTimeoutSeconds: 5}, router, lc)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+
+	slow := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			slow <- 0
+			return
+		}
+		resp.Body.Close()
+		slow <- resp.StatusCode
+	}()
+	<-started
+
+	cancel()
+	require.Eventually(t, lc.ShuttingDown, time.Second, 10*time.Millisecond)
+	assert.Empty(t, steps, "nothing stops while a request is in flight")
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-slow, "in-flight request is drained")
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	assert.Equal(t, []string{"request finished", "worker stopped", "database closed"}, steps)
+	assert.NoError(t, lc.Stop(context.Background()), "Stop is idempotent")
+}
+
+func TestShutdownStopsWorkersAfterSlowRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	lc := lifecycle.New(context.Background())
+	stopped := make(chan struct{})
+	lc.Go("flusher", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(50 * time.Millisecond)
+		close(stopped)
+	})
+	var closed bool
+	lc.OnClose("database", func() error {
+		select {
+		case <-stopped:
+			closed = true
+		default:
+		}
+		return nil
+	})
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	router := gin.New()
+	router.GET("/stuck", func(c *gin.Context) {
+		close(started)
+		<-release
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := server.New(config.ServerConfig{ShutdownTimeoutSeconds: 1}, router, lc)
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(ctx, ln) }()
+
+	go func() {
+		if resp, err := http.Get("http://" + ln.Addr().String() + "/stuck"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+	cancel()
+
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the request did not drain")
+	assert.NotContains(t, err.Error(), "workers did not stop", "workers get their own deadline")
+	assert.True(t, closed, "resources close after the workers stopped")
+}
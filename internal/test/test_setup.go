@@ -1,14 +1,27 @@
 package test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 
-	"github.com/luthfiarsyad/mms/config"
+	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/app"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/lifecycle"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/mysql"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
 )
 
 // TestConfig holds configuration for testing
@@ -82,7 +95,7 @@ func SetupTestDatabase(t *testing.T) *sql.DB {
 		},
 		Paseto: config.PasetoConfig{
 			SymmetricKey:  "JIusUnwiN236xiEVXMtRaJTNLyz6e0BD4U4pfX0CQNQ=",
-			ExpireMinutes: 60,
+			ExpireMinutes: 24 * 60,
 		},
 		Log: config.LogConfig{
 			Level: "info",
@@ -90,7 +103,7 @@ func SetupTestDatabase(t *testing.T) *sql.DB {
 	}
 	
 	// Set the global config
-	config.Cfg = cfg
+	config.Set(cfg)
 	
 	// First connect to MySQL server without specifying database
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/?parseTime=true&loc=Local",
@@ -139,7 +152,22 @@ func SetupTestDatabase(t *testing.T) *sql.DB {
 // CleanupTestDatabase cleans up test data after tests
 func CleanupTestDatabase(t *testing.T, db *sql.DB) {
 	// Clean up test data
-	_, err := db.Exec("DELETE FROM transactions")
+	_, err := db.Exec("DELETE FROM sessions")
+	if err != nil {
+		t.Logf("Warning: Failed to clean up sessions: %v", err)
+	}
+
+	_, err = db.Exec("DELETE FROM idempotency_keys")
+	if err != nil {
+		t.Logf("Warning: Failed to clean up idempotency keys: %v", err)
+	}
+
+	_, err = db.Exec("DELETE FROM api_keys")
+	if err != nil {
+		t.Logf("Warning: Failed to clean up api keys: %v", err)
+	}
+
+	_, err = db.Exec("DELETE FROM transactions")
 	if err != nil {
 		t.Logf("Warning: Failed to clean up transactions: %v", err)
 	}
@@ -155,45 +183,10 @@ func CleanupTestDatabase(t *testing.T, db *sql.DB) {
 	}
 }
 
-// runTestMigrations runs the database schema for testing
+// runTestMigrations applies the same migrations as the server
 func runTestMigrations(db *sql.DB) error {
 	log.Println("[TEST-DB] Running test migrations...")
-	
-	// Create users table
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS users (
-			id BIGINT AUTO_INCREMENT PRIMARY KEY,
-			name VARCHAR(255) NOT NULL,
-			email VARCHAR(255) NOT NULL UNIQUE,
-			password VARCHAR(255) NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
-	}
-
-	// Create transactions table
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS transactions (
-			id BIGINT AUTO_INCREMENT PRIMARY KEY,
-			user_id BIGINT NOT NULL,
-			amount DECIMAL(10,2) NOT NULL,
-			description VARCHAR(500) NOT NULL,
-			type ENUM('income', 'expense') NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
-			INDEX idx_user_id (user_id),
-			INDEX idx_created_at (created_at)
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("failed to create transactions table: %w", err)
-	}
-	
-	log.Println("[TEST-DB] Test migrations completed successfully")
-	return nil
+	return mysql.Migrate(context.Background(), db)
 }
 
 // TestHelper provides common test utilities
@@ -250,4 +243,69 @@ func (th *TestHelper) CreateTestTransaction(userID int64, amount float64, descri
 	}
 	
 	return id
-}
\ No newline at end of file
+}
+
+// CreateTestToken starts a login session for userID and returns an access
+// token referencing it, as AuthUsecase.Login would.
+func (th *TestHelper) CreateTestToken(userID int64) string {
+	sessions := session.NewService(mysql.NewSessionRepo(th.DB))
+	sess, err := sessions.Start(context.Background(), userID, "127.0.0.1", "test", time.Hour)
+	if err != nil {
+		th.T.Fatalf("Failed to create test session: %v", err)
+	}
+
+	pas, err := security.NewPasetoService(config.Get().Paseto)
+	if err != nil {
+		th.T.Fatalf("Failed to create PASETO service: %v", err)
+	}
+	token, err := pas.CreateToken(userID, sess.ID, time.Hour)
+	if err != nil {
+		th.T.Fatalf("Failed to create test token: %v", err)
+	}
+
+	return token
+}
+
+// performJSON sends body as JSON through router and returns the recorded response.
+func performJSON(t *testing.T, router http.Handler, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatalf("Failed to encode request body: %v", err)
+		}
+	}
+	req, _ := http.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// errorResponse is the JSON body rendered by middleware.ErrorHandler.
+type errorResponse struct {
+	Error struct {
+		Code    string          `json:"code"`
+		Message string          `json:"message"`
+		Details json.RawMessage `json:"details"`
+	} `json:"error"`
+}
+
+// testLifecycle returns a lifecycle manager that is never shut down.
+func testLifecycle() *lifecycle.Manager {
+	return lifecycle.New(context.Background())
+}
+
+// mountRoutes serves the API on router from the MySQL test database db,
+// built with the current config.
+func mountRoutes(t *testing.T, router *gin.Engine, db *sql.DB, lc *lifecycle.Manager) {
+	t.Helper()
+	repos := store.NewMySQL(db, mysql.NewRouter(db, nil, 0))
+	if err := app.Routes(router, config.Get(), repos, lc); err != nil {
+		t.Fatalf("Failed to set up routes: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestTracing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	helper := NewTestHelper(t)
+	defer helper.Cleanup()
+
+	userID := helper.CreateTestUser("Traced User", "spans@example.com", "unused")
+	bearer := "Bearer " + helper.CreateTestToken(userID)
+
+	var logs bytes.Buffer
+	previous := logger.L
+	logger.L = zerolog.New(&logs)
+	defer func() { logger.L = previous }()
+
+	_, err := tracing.Init(context.Background(), config.TracingConfig{Exporter: tracing.ExporterOff})
+	require.NoError(t, err)
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	router := gin.New()
+	mountRoutes(t, router, helper.DB, testLifecycle())
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{
+		Amount: 7, Description: "secret description", Type: "income",
+	}, map[string]string{
+		"Authorization": bearer,
+		"traceparent":   "00-" + traceID + "-00f067aa0ba902b7-01",
+	})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	spans := recorder.Ended()
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range spans {
+		assert.Equal(t, traceID, s.SpanContext().TraceID().String(), "span %s continues the incoming trace", s.Name())
+		byName[s.Name()] = s
+	}
+
+	server, ok := byName["POST /api/v1/transactions"]
+	require.True(t, ok, "server span named after the route template")
+	usecase, ok := byName["TransactionUsecase.CreateTransaction"]
+	require.True(t, ok, "usecase span")
+	assert.Equal(t, server.SpanContext().SpanID(), usecase.Parent().SpanID())
+
+	insert, ok := byName["INSERT transactions"]
+	require.True(t, ok, "query span")
+	for _, attr := range insert.Attributes() {
+		if attr.Key == "db.query.text" {
+			assert.True(t, strings.HasPrefix(attr.Value.AsString(), "INSERT INTO transactions"))
+			assert.NotContains(t, attr.Value.AsString(), "secret description")
+		}
+	}
+
+	assert.Contains(t, logs.String(), `"trace_id":"`+traceID+`"`)
+}
@@ -0,0 +1,265 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+type batchResult struct {
+	Index  int                      `json:"index"`
+	Op     string                   `json:"op"`
+	ID     int64                    `json:"id"`
+	Status int                      `json:"status"`
+	Data   *transaction.Transaction `json:"data"`
+	Error  *struct {
+		Code string `json:"code"`
+	} `json:"error"`
+}
+
+func TestTransactionBatch(t *testing.T) {
+	router, _ := newMemoryServer(t)
+	owner := registerAndLogin(t, router, "bulk@example.com")
+	other := registerAndLogin(t, router, "neighbour@example.com")
+
+	create := func(auth map[string]string, description string) transaction.Transaction {
+		t.Helper()
+		w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{Amount: 10, Description: description, Type: "expense"}, auth)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		var resp struct {
+			Data transaction.Transaction `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp.Data
+	}
+	count := func(auth map[string]string) int {
+		t.Helper()
+		w := performJSON(t, router, "GET", "/api/v1/transactions", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		var page struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return len(page.Data)
+	}
+	batch := func(t *testing.T, body any) (int, []batchResult) {
+		t.Helper()
+		w := performJSON(t, router, "POST", "/api/v1/transactions/batch", body, owner)
+		var resp struct {
+			Data  []batchResult `json:"data"`
+			Error struct {
+				Code    string        `json:"code"`
+				Details []batchResult `json:"details"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp), w.Body.String())
+		if w.Code == http.StatusOK {
+			return w.Code, resp.Data
+		}
+		return w.Code, resp.Error.Details
+	}
+
+	mine := create(owner, "imported")
+	doomed := create(owner, "duplicate")
+	theirs := create(other, "not yours")
+
+	t.Run("Best effort runs every operation on its own", func(t *testing.T) {
+		code, results := batch(t, map[string]any{
+			"operations": []map[string]any{
+				{"op": "create", "amount": 5, "description": "coffee", "type": "expense"},
+				{"op": "update", "id": mine.ID, "version": mine.Version, "type": "income"},
+				{"op": "delete", "id": theirs.ID},
+				{"op": "create", "amount": 7, "description": "bus", "type": "expense"},
+				{"op": "delete", "id": doomed.ID},
+				{"op": "update", "id": mine.ID, "version": mine.Version, "description": "stale"},
+			},
+		})
+		require.Equal(t, http.StatusOK, code)
+		require.Len(t, results, 6)
+		for i, r := range results {
+			assert.Equal(t, i, r.Index)
+		}
+
+		assert.Equal(t, http.StatusCreated, results[0].Status)
+		require.NotNil(t, results[0].Data)
+		assert.Equal(t, "coffee", results[0].Data.Description)
+		assert.Equal(t, results[0].Data.ID, results[0].ID)
+		assert.Less(t, results[0].ID, results[3].ID, "operations run in request order")
+
+		assert.Equal(t, http.StatusOK, results[1].Status)
+		require.NotNil(t, results[1].Data)
+		assert.Equal(t, "income", results[1].Data.Type)
+		assert.Equal(t, mine.Version+1, results[1].Data.Version)
+
+		assert.Equal(t, http.StatusNotFound, results[2].Status, "other users' transactions are not found")
+		assert.Equal(t, "TRANSACTION_NOT_FOUND", results[2].Error.Code)
+		assert.Equal(t, http.StatusNoContent, results[4].Status)
+		assert.Equal(t, http.StatusPreconditionFailed, results[5].Status)
+		assert.Equal(t, "VERSION_CONFLICT", results[5].Error.Code)
+
+		assert.Equal(t, 3, count(owner))
+		assert.Equal(t, 1, count(other))
+	})
+
+	t.Run("Atomic batches are all or nothing", func(t *testing.T) {
+		code, results := batch(t, map[string]any{
+			"atomic": true,
+			"operations": []map[string]any{
+				{"op": "create", "amount": 3, "description": "snack", "type": "expense"},
+				{"op": "update", "id": mine.ID, "version": mine.Version + 1, "amount": 99},
+				{"op": "delete", "id": theirs.ID},
+			},
+		})
+		require.Equal(t, http.StatusUnprocessableEntity, code)
+		require.Len(t, results, 3)
+		assert.Equal(t, "BATCH_ABORTED", results[0].Error.Code)
+		assert.Nil(t, results[0].Data, "rolled back creates are not returned")
+		assert.Equal(t, "BATCH_ABORTED", results[1].Error.Code)
+		assert.Equal(t, "TRANSACTION_NOT_FOUND", results[2].Error.Code)
+
+		assert.Equal(t, 3, count(owner), "the create is rolled back")
+		w := performJSON(t, router, "GET", fmt.Sprintf("/api/v1/transactions/%d", mine.ID), nil, owner)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"), "and so is the update")
+
+		code, results = batch(t, map[string]any{
+			"atomic": true,
+			"operations": []map[string]any{
+				{"op": "create", "amount": 3, "description": "snack", "type": "expense"},
+				{"op": "update", "id": mine.ID, "version": mine.Version + 1, "amount": 99},
+			},
+		})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, http.StatusCreated, results[0].Status)
+		assert.Equal(t, 99.0, results[1].Data.Amount)
+		assert.Equal(t, 4, count(owner))
+	})
+
+	t.Run("Operations are validated", func(t *testing.T) {
+		for name, op := range map[string]map[string]any{
+			"unknown op":             {"op": "upsert", "id": 1},
+			"create without type":    {"op": "create", "amount": 1, "description": "x"},
+			"empty description":      {"op": "update", "id": mine.ID, "version": 1, "description": ""},
+			"update without id":      {"op": "update", "version": 1, "amount": 1},
+			"update without version": {"op": "update", "id": mine.ID, "amount": 1},
+			"delete without id":      {"op": "delete"},
+		} {
+			w := performJSON(t, router, "POST", "/api/v1/transactions/batch", map[string]any{"operations": []any{op}}, owner)
+			assert.Equal(t, http.StatusBadRequest, w.Code, name)
+		}
+
+		w := performJSON(t, router, "POST", "/api/v1/transactions/batch", map[string]any{"operations": []any{}}, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "a batch needs operations")
+		ops := make([]map[string]any, transaction.MaxBatchSize+1)
+		for i := range ops {
+			ops[i] = map[string]any{"op": "delete", "id": 1}
+		}
+		w = performJSON(t, router, "POST", "/api/v1/transactions/batch", map[string]any{"operations": ops}, owner)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "batches are capped")
+
+		code, results := batch(t, map[string]any{"operations": []map[string]any{{"op": "update", "id": mine.ID, "version": 1}}})
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "EMPTY_PATCH", results[0].Error.Code)
+	})
+}
+
+func TestTransactionBatchIsAudited(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Bulk", Email: "bulk-audit@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+	audits := audit.NewService(repos.Audit, repos.Transactor)
+	service := transaction.NewService(repos.Transactions, audits)
+
+	amount, description, kind := 5.0, "imported", "expense"
+	ops := []transaction.Operation{
+		{Kind: transaction.OpCreate, Fields: transaction.Patch{Amount: &amount, Description: &description, Type: &kind}},
+		{Kind: transaction.OpCreate, Fields: transaction.Patch{Amount: &amount, Description: &description, Type: &kind}},
+	}
+	results, err := service.Batch(ctx, u.ID, ops, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	records, err := audits.List(ctx, audit.Filter{EntityType: audit.EntityTransaction})
+	require.NoError(t, err)
+	require.Len(t, records, 2, "every created transaction is recorded")
+	checked, err := audits.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, checked)
+
+	results, err = service.Batch(ctx, u.ID, []transaction.Operation{{Kind: transaction.OpDelete, ID: results[0].Transaction.ID}, ops[0]}, true)
+	require.NoError(t, err)
+	records, err = audits.List(ctx, audit.Filter{EntityType: audit.EntityTransaction})
+	require.NoError(t, err)
+	order := map[audit.Action]int64{}
+	for _, r := range records {
+		if r.Action == audit.ActionDelete || r.EntityID == results[1].Transaction.ID {
+			order[r.Action] = r.ID
+		}
+	}
+	require.Len(t, order, 2)
+	assert.Less(t, order[audit.ActionDelete], order[audit.ActionCreate], "the delete runs before the create that follows it")
+
+	failing := transaction.NewService(repos.Transactions, audit.NewService(failingAuditRepo{repos.Audit}, repos.Transactor))
+	results, err = failing.Batch(ctx, u.ID, ops, false)
+	require.NoError(t, err)
+	assert.ErrorIs(t, results[0].Err, errAuditDown)
+	list, err := repos.Transactions.FindByUserID(ctx, u.ID, transaction.Page{})
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "creates that cannot be audited are not stored")
+}
+
+func TestBestEffortBatchGroupsCreates(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Bulk", Email: "bulk-insert@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+	counted := &countingCreates{Repository: repos.Transactions}
+	service := transaction.NewService(counted, audit.NewService(repos.Audit, repos.Transactor))
+
+	amount, zero, description, kind := 5.0, 0.0, "imported", "expense"
+	valid := transaction.Operation{Kind: transaction.OpCreate, Fields: transaction.Patch{Amount: &amount, Description: &description, Type: &kind}}
+	invalid := transaction.Operation{Kind: transaction.OpCreate, Fields: transaction.Patch{Amount: &zero, Description: &description, Type: &kind}}
+
+	results, err := service.Batch(ctx, u.ID, []transaction.Operation{valid, valid, valid}, false)
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+	}
+	assert.Equal(t, 1, counted.calls, "adjacent creates are stored with one insert")
+
+	counted.calls = 0
+	results, err = service.Batch(ctx, u.ID, []transaction.Operation{valid, invalid, valid}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.NotNil(t, results[0].Transaction)
+	assert.ErrorIs(t, results[1].Err, transaction.ErrInvalidAmount, "the failing create is reported")
+	assert.Nil(t, results[1].Transaction)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, 2, counted.calls, "the others are retried one by one")
+	list, err := repos.Transactions.FindByUserID(ctx, u.ID, transaction.Page{})
+	require.NoError(t, err)
+	assert.Len(t, list, 5)
+}
+
+// countingCreates counts the multi-row inserts of a transaction repository.
+type countingCreates struct {
+	transaction.Repository
+	calls int
+}
+
+func (r *countingCreates) CreateMany(ctx context.Context, ts []*transaction.Transaction) error {
+	r.calls++
+	return r.Repository.CreateMany(ctx, ts)
+}
@@ -0,0 +1,154 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+)
+
+func TestTransactionVersioning(t *testing.T) {
+	router, _ := newMemoryServer(t)
+	owner := registerAndLogin(t, router, "versioned@example.com")
+
+	w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{Amount: 30, Description: "groceries", Type: "expense"}, owner)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+	var created struct {
+		Data transaction.Transaction `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := fmt.Sprintf("/api/v1/transactions/%d", created.Data.ID)
+
+	withIfMatch := func(etag string) map[string]string {
+		return map[string]string{"Authorization": owner["Authorization"], "If-Match": etag}
+	}
+	errorCode := func(t *testing.T, body []byte) string {
+		t.Helper()
+		var resp errorResponse
+		require.NoError(t, json.Unmarshal(body, &resp))
+		return resp.Error.Code
+	}
+	decode := func(t *testing.T, body []byte) transaction.Transaction {
+		t.Helper()
+		var resp struct {
+			Data transaction.Transaction `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(body, &resp))
+		return resp.Data
+	}
+
+	w = performJSON(t, router, "GET", path, nil, owner)
+	require.Equal(t, http.StatusOK, w.Code)
+	stored := decode(t, w.Body.Bytes())
+
+	t.Run("GET returns the version as ETag", func(t *testing.T) {
+		assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+		assert.Equal(t, int64(1), stored.Version)
+	})
+
+	t.Run("Updates need If-Match", func(t *testing.T) {
+		body := request.UpdateTransactionRequest{Amount: 45, Description: "groceries", Type: "expense"}
+		w := performJSON(t, router, "PUT", path, body, owner)
+		require.Equal(t, http.StatusPreconditionRequired, w.Code, w.Body.String())
+		assert.Equal(t, "PRECONDITION_REQUIRED", errorCode(t, w.Body.Bytes()))
+
+		w = performJSON(t, router, "PATCH", path, map[string]any{"amount": 45}, owner)
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+	})
+
+	t.Run("PUT replaces the transaction", func(t *testing.T) {
+		body := request.UpdateTransactionRequest{Amount: 45, Description: "weekly groceries", Type: "expense"}
+		w := performJSON(t, router, "PUT", path, body, withIfMatch(`"0", "1"`))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		updated := decode(t, w.Body.Bytes())
+		assert.Equal(t, 45.0, updated.Amount)
+		assert.Equal(t, int64(2), updated.Version)
+		assert.Equal(t, stored.UserID, updated.UserID, "fields outside the update are kept")
+		assert.True(t, stored.CreatedAt.Equal(updated.CreatedAt))
+	})
+
+	t.Run("Stale updates are refused", func(t *testing.T) {
+		w := performJSON(t, router, "PATCH", path, map[string]any{"amount": 99}, withIfMatch(`"1"`))
+		require.Equal(t, http.StatusPreconditionFailed, w.Code, w.Body.String())
+		assert.Equal(t, "VERSION_CONFLICT", errorCode(t, w.Body.Bytes()))
+		assert.Equal(t, http.StatusPreconditionFailed, performJSON(t, router, "PATCH", path, map[string]any{"amount": 99}, withIfMatch(`W/"2"`)).Code,
+			"weak tags never match")
+
+		w = performJSON(t, router, "GET", path, nil, owner)
+		assert.Equal(t, 45.0, decode(t, w.Body.Bytes()).Amount)
+	})
+
+	t.Run("PATCH changes only the given fields", func(t *testing.T) {
+		w := performJSON(t, router, "PATCH", path, map[string]any{"description": "snacks"}, withIfMatch(`"2"`))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		patched := decode(t, w.Body.Bytes())
+		assert.Equal(t, "snacks", patched.Description)
+		assert.Equal(t, 45.0, patched.Amount)
+		assert.Equal(t, "expense", patched.Type)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+		w = performJSON(t, router, "PATCH", path, map[string]any{"type": "income"}, withIfMatch("*"))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "income", decode(t, w.Body.Bytes()).Type)
+
+		assert.Equal(t, http.StatusBadRequest, performJSON(t, router, "PATCH", path, map[string]any{}, withIfMatch(`"4"`)).Code)
+		assert.Equal(t, http.StatusBadRequest, performJSON(t, router, "PATCH", path, map[string]any{"amount": -1}, withIfMatch(`"4"`)).Code)
+		w = performJSON(t, router, "PATCH", path, map[string]any{"description": ""}, withIfMatch(`"4"`))
+		assert.Equal(t, http.StatusBadRequest, w.Code, "a description cannot be cleared")
+		assert.Contains(t, w.Body.String(), `"field":"description"`)
+		w = performJSON(t, router, "GET", path, nil, owner)
+		assert.Equal(t, "snacks", decode(t, w.Body.Bytes()).Description)
+	})
+}
+
+func TestConcurrentTransactionUpdates(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Racer", Email: "racer@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+	service := transaction.NewService(repos.Transactions, audit.NewService(repos.Audit, repos.Transactor))
+	tx := &transaction.Transaction{UserID: u.ID, Amount: 10, Description: "shared", Type: "expense"}
+	require.NoError(t, service.Create(ctx, tx))
+
+	const devices = 8
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		winner []string
+	)
+	for i := 0; i < devices; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			description := fmt.Sprintf("device %d", i)
+			_, err := service.Update(ctx, tx.ID, tx.Version, transaction.Patch{Description: &description})
+			if err != nil {
+				assert.ErrorIs(t, err, transaction.ErrVersionConflict)
+				return
+			}
+			mu.Lock()
+			winner = append(winner, description)
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, winner, 1, "only one edit of version 1 is stored")
+	found, err := service.GetByID(ctx, tx.ID)
+	require.NoError(t, err)
+	assert.Equal(t, winner[0], found.Description)
+	assert.Equal(t, int64(2), found.Version)
+}
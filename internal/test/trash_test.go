@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/app"
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/domain/transaction"
+	"github.com/luthfiarsyad/mms/internal/domain/user"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/persistence/store"
+	"github.com/luthfiarsyad/mms/internal/interface/http/request"
+	"github.com/luthfiarsyad/mms/internal/usecase"
+)
+
+// newMemoryServer serves the API from an in-memory store with the default
+// config.
+func newMemoryServer(t *testing.T) (*gin.Engine, *store.Store) {
+	return newMemoryServerWithConfig(t, "")
+}
+
+// newMemoryServerWithConfig is newMemoryServer with extra YAML appended to
+// the config.
+func newMemoryServerWithConfig(t *testing.T, extra string) (*gin.Engine, *store.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg, err := config.Read(writeConfigFile(t, `
+database:
+  driver: "memory"
+password:
+  algorithm: "bcrypt"
+paseto:
+  symmetric_key: "`+testPasetoKey+`"
+`+extra))
+	require.NoError(t, err)
+	previous := config.Get()
+	config.Set(cfg)
+	t.Cleanup(func() { config.Set(previous) })
+
+	repos := store.NewMemory()
+	lc := testLifecycle()
+	t.Cleanup(func() { lc.Stop(context.Background()) })
+	router := gin.New()
+	require.NoError(t, app.Routes(router, cfg, repos, lc))
+	return router, repos
+}
+
+// registerAndLogin creates a user through the API and returns the
+// Authorization header of its session.
+func registerAndLogin(t *testing.T, router http.Handler, email string) map[string]string {
+	t.Helper()
+	w := performJSON(t, router, "POST", "/api/v1/auth/register", request.RegisterRequest{
+		Name: "Trash User", Email: email, Password: "password123",
+	}, nil)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = performJSON(t, router, "POST", "/api/v1/auth/login", request.LoginRequest{Email: email, Password: "password123"}, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var login struct {
+		Data struct {
+			Token string `json:"access_token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &login))
+	return map[string]string{"Authorization": "Bearer " + login.Data.Token}
+}
+
+func TestTransactionTrash(t *testing.T) {
+	router, _ := newMemoryServer(t)
+	owner := registerAndLogin(t, router, "owner@example.com")
+	other := registerAndLogin(t, router, "other@example.com")
+
+	w := performJSON(t, router, "POST", "/api/v1/transactions", request.CreateTransactionRequest{
+		Amount: 30, Description: "groceries", Type: "expense",
+	}, owner)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data transaction.Transaction `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	path := fmt.Sprintf("/api/v1/transactions/%d", created.Data.ID)
+
+	list := func(path string, auth map[string]string) []transaction.Transaction {
+		t.Helper()
+		w := performJSON(t, router, "GET", path, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var page struct {
+			Data []transaction.Transaction `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+		return page.Data
+	}
+
+	require.Equal(t, http.StatusNoContent, performJSON(t, router, "DELETE", path, nil, owner).Code)
+
+	t.Run("Deleted transactions move to the trash", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, performJSON(t, router, "GET", path, nil, owner).Code)
+		assert.Empty(t, list("/api/v1/transactions", owner))
+
+		trash := list("/api/v1/transactions/trash", owner)
+		require.Len(t, trash, 1)
+		assert.Equal(t, created.Data.ID, trash[0].ID)
+		assert.NotNil(t, trash[0].DeletedAt)
+		assert.Empty(t, list("/api/v1/transactions/trash", other), "each user sees only their trash")
+	})
+
+	t.Run("Only the owner restores", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, performJSON(t, router, "POST", path+"/restore", nil, other).Code)
+		assert.Equal(t, http.StatusBadRequest, performJSON(t, router, "POST", "/api/v1/transactions/abc/restore", nil, owner).Code)
+
+		w := performJSON(t, router, "POST", path+"/restore", nil, owner)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "deleted_at")
+		assert.Equal(t, http.StatusNotFound, performJSON(t, router, "POST", path+"/restore", nil, owner).Code, "no longer in the trash")
+
+		assert.Equal(t, http.StatusOK, performJSON(t, router, "GET", path, nil, owner).Code)
+		assert.Empty(t, list("/api/v1/transactions/trash", owner))
+	})
+}
+
+func TestTrashPurge(t *testing.T) {
+	ctx := context.Background()
+	repos := store.NewMemory()
+	u := &user.User{Name: "Purge User", Email: "purge@example.com", Password: "hash"}
+	require.NoError(t, repos.Users.Create(ctx, u))
+	owner := u.ID
+
+	old := &transaction.Transaction{UserID: owner, Amount: 1, Description: "old", Type: "expense"}
+	recent := &transaction.Transaction{UserID: owner, Amount: 2, Description: "recent", Type: "expense"}
+	for _, tx := range []*transaction.Transaction{old, recent} {
+		require.NoError(t, repos.Transactions.Create(ctx, tx))
+	}
+	require.NoError(t, repos.Transactions.Delete(ctx, old.ID, time.Now().Add(-31*24*time.Hour)))
+	require.NoError(t, repos.Transactions.Delete(ctx, recent.ID, time.Now()))
+
+	// The purge runs once right away, then every interval until cancelled.
+	runCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	audits := audit.NewService(repos.Audit, repos.Transactor)
+	uc := usecase.NewTransactionUsecase(transaction.NewService(repos.Transactions, audits))
+	uc.RunTrashPurge(runCtx, 30*24*time.Hour, time.Hour)
+
+	trash, err := repos.Transactions.FindDeletedByUserID(ctx, owner, transaction.Page{})
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, recent.ID, trash[0].ID)
+
+	records, err := audits.List(ctx, audit.Filter{EntityType: audit.EntityTransaction})
+	require.NoError(t, err)
+	require.Len(t, records, 1, "the purge is recorded")
+	assert.Equal(t, audit.ActionPurge, records[0].Action)
+	assert.Equal(t, old.ID, records[0].EntityID)
+	assert.Zero(t, records[0].ActorID, "no user purged it")
+	assert.Contains(t, string(records[0].Before), `"description":"old"`)
+}
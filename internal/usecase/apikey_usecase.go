@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/luthfiarsyad/mms/internal/domain/apikey"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/rs/zerolog"
+)
+
+// lastUsedInterval is the minimum time between last_used_at updates of a
+// key, so busy clients do not write on every request.
+const lastUsedInterval = time.Minute
+
+type APIKeyUsecase struct {
+	keyService *apikey.Service
+}
+
+func NewAPIKeyUsecase(keyService *apikey.Service) *APIKeyUsecase {
+	logger.L.Debug().Msg("APIKeyUsecase: initialized")
+	return &APIKeyUsecase{keyService: keyService}
+}
+
+// CreateKey returns the stored key and the raw key, which must be shown to
+// the user once and is never retrievable again.
+func (u *APIKeyUsecase) CreateKey(ctx context.Context, userID int64, name string, scopes []string, expiresAt *time.Time) (_ *apikey.APIKey, _ string, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUsecase.CreateKey")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Str("name", name).
+		Strs("scopes", scopes).
+		Msg("APIKeyUsecase.CreateKey: creating api key")
+
+	k, raw, err := u.keyService.Create(ctx, userID, name, scopes, expiresAt)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("APIKeyUsecase.CreateKey: failed to create api key")
+		return nil, "", err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int64("api_key_id", k.ID).
+		Int64("user_id", userID).
+		Str("prefix", k.Prefix).
+		Msg("APIKeyUsecase.CreateKey: api key created successfully")
+
+	return k, raw, nil
+}
+
+func (u *APIKeyUsecase) ListKeys(ctx context.Context, userID int64) (_ []*apikey.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUsecase.ListKeys")
+	defer tracing.End(span, &err)
+
+	keys, err := u.keyService.ListByUser(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("APIKeyUsecase.ListKeys: failed to list api keys")
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (u *APIKeyUsecase) RevokeKey(ctx context.Context, userID, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUsecase.RevokeKey")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("api_key_id", id).
+		Int64("user_id", userID).
+		Msg("APIKeyUsecase.RevokeKey: revoking api key")
+
+	if err := u.keyService.Revoke(ctx, id, userID); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("api_key_id", id).
+			Int64("user_id", userID).
+			Msg("APIKeyUsecase.RevokeKey: failed to revoke api key")
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int64("api_key_id", id).
+		Int64("user_id", userID).
+		Msg("APIKeyUsecase.RevokeKey: api key revoked successfully")
+
+	return nil
+}
+
+// AuthenticateAPIKey resolves a raw key presented by a client. last_used_at
+// is written at most once per lastUsedInterval.
+func (u *APIKeyUsecase) AuthenticateAPIKey(ctx context.Context, raw string) (_ *apikey.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "APIKeyUsecase.AuthenticateAPIKey")
+	defer tracing.End(span, &err)
+
+	k, err := u.keyService.Authenticate(ctx, raw)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Msg("APIKeyUsecase.AuthenticateAPIKey: api key rejected")
+		return nil, err
+	}
+
+	now := time.Now()
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= lastUsedInterval {
+		if err := u.keyService.TouchLastUsed(ctx, k, now); err != nil {
+			// Not fatal: the key is still valid.
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Int64("api_key_id", k.ID).
+				Msg("APIKeyUsecase.AuthenticateAPIKey: failed to update last used")
+		}
+	}
+	return k, nil
+}
@@ -0,0 +1,75 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luthfiarsyad/mms/internal/domain/audit"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/rs/zerolog"
+)
+
+type AuditUsecase struct {
+	auditService *audit.Service
+}
+
+func NewAuditUsecase(auditService *audit.Service) *AuditUsecase {
+	logger.L.Debug().Msg("AuditUsecase: initialized")
+	return &AuditUsecase{auditService: auditService}
+}
+
+// ListUserRecords returns the records of the changes the user made that
+// match f, newest first, and how many match in total ignoring f.Limit and
+// f.Offset. f.ActorID is ignored.
+func (u *AuditUsecase) ListUserRecords(ctx context.Context, userID int64, f audit.Filter) (_ []*audit.Record, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "AuditUsecase.ListUserRecords")
+	defer tracing.End(span, &err)
+
+	f.ActorID = userID
+	records, err := u.auditService.List(ctx, f)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuditUsecase.ListUserRecords: failed to list audit records")
+		return nil, 0, err
+	}
+	total, err := u.auditService.Count(ctx, f)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuditUsecase.ListUserRecords: failed to count audit records")
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+// VerifyChain checks the whole audit log and returns how many records it
+// checked. A broken chain is reported with an error wrapping
+// audit.ErrTampered.
+func (u *AuditUsecase) VerifyChain(ctx context.Context) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "AuditUsecase.VerifyChain")
+	defer tracing.End(span, &err)
+
+	checked, err := u.auditService.Verify(ctx)
+	if err != nil {
+		if errors.Is(err, audit.ErrTampered) {
+			zerolog.Ctx(ctx).Error().
+				Err(err).
+				Int("checked", checked).
+				Msg("AuditUsecase.VerifyChain: audit chain is broken")
+		} else {
+			zerolog.Ctx(ctx).Error().
+				Err(err).
+				Msg("AuditUsecase.VerifyChain: failed to verify audit chain")
+		}
+		return checked, err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int("checked", checked).
+		Msg("AuditUsecase.VerifyChain: audit chain is intact")
+	return checked, nil
+}
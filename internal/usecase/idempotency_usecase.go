@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/luthfiarsyad/mms/internal/domain/idempotency"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/rs/zerolog"
+)
+
+type IdempotencyUsecase struct {
+	service *idempotency.Service
+}
+
+func NewIdempotencyUsecase(service *idempotency.Service) *IdempotencyUsecase {
+	logger.L.Debug().Msg("IdempotencyUsecase: initialized")
+	return &IdempotencyUsecase{service: service}
+}
+
+// Begin claims key for the user's request identified by hash. It returns
+// the stored record when the request already succeeded and nil when it
+// should run.
+func (u *IdempotencyUsecase) Begin(ctx context.Context, userID int64, key, hash string) (_ *idempotency.Record, err error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUsecase.Begin")
+	defer tracing.End(span, &err)
+
+	record, err := u.service.Begin(ctx, userID, key, hash)
+	if err != nil {
+		if errors.Is(err, idempotency.ErrKeyReused) || errors.Is(err, idempotency.ErrInProgress) {
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Int64("user_id", userID).
+				Msg("IdempotencyUsecase.Begin: idempotency key rejected")
+		} else if !errors.Is(err, idempotency.ErrInvalidKey) {
+			zerolog.Ctx(ctx).Error().
+				Err(err).
+				Int64("user_id", userID).
+				Msg("IdempotencyUsecase.Begin: failed to claim idempotency key")
+		}
+		return nil, err
+	}
+	if record != nil {
+		zerolog.Ctx(ctx).Info().
+			Int64("user_id", userID).
+			Int("status", record.Status).
+			Msg("IdempotencyUsecase.Begin: replaying stored response")
+	}
+	return record, nil
+}
+
+// Complete stores the response to replay for key.
+func (u *IdempotencyUsecase) Complete(ctx context.Context, userID int64, key string, status int, header map[string]string, body []byte) (err error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUsecase.Complete")
+	defer tracing.End(span, &err)
+
+	if err = u.service.Complete(ctx, userID, key, status, header, body); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("IdempotencyUsecase.Complete: failed to store response")
+	}
+	return err
+}
+
+// Release frees key after its request failed.
+func (u *IdempotencyUsecase) Release(ctx context.Context, userID int64, key string) (err error) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUsecase.Release")
+	defer tracing.End(span, &err)
+
+	if err = u.service.Release(ctx, userID, key); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("IdempotencyUsecase.Release: failed to release idempotency key")
+	}
+	return err
+}
+
+// RunPurge deletes expired idempotency records every interval until ctx is
+// done. Begin already ignores expired records, so nothing is purged at start.
+func (u *IdempotencyUsecase) RunPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.purge(ctx)
+		}
+	}
+}
+
+func (u *IdempotencyUsecase) purge(ctx context.Context) {
+	ctx, span := tracing.Start(ctx, "IdempotencyUsecase.Purge")
+	var err error
+	defer tracing.End(span, &err)
+
+	purged, err := u.service.Purge(ctx)
+	if err != nil {
+		logger.L.Error().Err(err).Msg("IdempotencyUsecase.Purge: failed to purge idempotency keys")
+		return
+	}
+	if purged > 0 {
+		logger.L.Info().Int64("count", purged).Msg("IdempotencyUsecase.Purge: purged expired idempotency keys")
+	}
+}
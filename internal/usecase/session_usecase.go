@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luthfiarsyad/mms/config"
+	"github.com/luthfiarsyad/mms/internal/domain/session"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/rs/zerolog"
+)
+
+const (
+	defaultSessionCacheTTL  = 30 * time.Second
+	defaultLastSeenInterval = time.Minute
+	maxCachedSessions       = 10000
+)
+
+type SessionUsecase struct {
+	sessionService   *session.Service
+	cacheTTL         time.Duration
+	lastSeenInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cachedSession
+}
+
+// cachedSession remembers a recently validated session so AuthMiddleware
+// does not hit the database on every request.
+type cachedSession struct {
+	userID     int64
+	validUntil time.Time
+	lastSeen   time.Time
+}
+
+func NewSessionUsecase(sessionService *session.Service, cfg config.SessionConfig) *SessionUsecase {
+	logger.L.Debug().Msg("SessionUsecase: initialized")
+	u := &SessionUsecase{
+		sessionService:   sessionService,
+		cacheTTL:         time.Duration(cfg.CacheTTLSeconds) * time.Second,
+		lastSeenInterval: time.Duration(cfg.LastSeenIntervalSeconds) * time.Second,
+		cache:            make(map[string]*cachedSession),
+	}
+	if u.cacheTTL <= 0 {
+		u.cacheTTL = defaultSessionCacheTTL
+	}
+	if u.lastSeenInterval <= 0 {
+		u.lastSeenInterval = defaultLastSeenInterval
+	}
+	return u
+}
+
+// CheckSession verifies that sessionID is an active session of userID.
+// Results are cached for the configured TTL, and last_seen_at is written at
+// most once per configured interval.
+func (u *SessionUsecase) CheckSession(ctx context.Context, sessionID string, userID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "SessionUsecase.CheckSession")
+	defer tracing.End(span, &err)
+
+	now := time.Now()
+
+	u.mu.Lock()
+	entry, ok := u.cache[sessionID]
+	if ok && (entry.userID != userID || now.After(entry.validUntil)) {
+		delete(u.cache, sessionID)
+		ok = false
+	}
+	u.mu.Unlock()
+
+	if !ok {
+		sess, err := u.sessionService.Validate(ctx, sessionID, userID)
+		if err != nil {
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Int64("user_id", userID).
+				Msg("SessionUsecase.CheckSession: session rejected")
+			return err
+		}
+		validUntil := now.Add(u.cacheTTL)
+		if sess.ExpiresAt.Before(validUntil) {
+			validUntil = sess.ExpiresAt
+		}
+		entry = &cachedSession{userID: userID, validUntil: validUntil, lastSeen: sess.LastSeenAt}
+
+		u.mu.Lock()
+		if len(u.cache) >= maxCachedSessions {
+			u.pruneLocked(now)
+		}
+		u.cache[sessionID] = entry
+		u.mu.Unlock()
+	}
+
+	u.mu.Lock()
+	touch := now.Sub(entry.lastSeen) >= u.lastSeenInterval
+	if touch {
+		entry.lastSeen = now
+	}
+	u.mu.Unlock()
+
+	if touch {
+		if err := u.sessionService.TouchLastSeen(ctx, sessionID, now); err != nil {
+			// Not fatal: the request is still authenticated.
+			zerolog.Ctx(ctx).Warn().
+				Err(err).
+				Int64("user_id", userID).
+				Msg("SessionUsecase.CheckSession: failed to update last seen")
+		}
+	}
+	return nil
+}
+
+func (u *SessionUsecase) ListSessions(ctx context.Context, userID int64) (_ []*session.Session, err error) {
+	ctx, span := tracing.Start(ctx, "SessionUsecase.ListSessions")
+	defer tracing.End(span, &err)
+
+	sessions, err := u.sessionService.ListActive(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("SessionUsecase.ListSessions: failed to list sessions")
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (u *SessionUsecase) RevokeSession(ctx context.Context, userID int64, sessionID string) (err error) {
+	ctx, span := tracing.Start(ctx, "SessionUsecase.RevokeSession")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Msg("SessionUsecase.RevokeSession: revoking session")
+
+	if err := u.sessionService.Revoke(ctx, sessionID, userID); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("SessionUsecase.RevokeSession: failed to revoke session")
+		return err
+	}
+
+	u.mu.Lock()
+	delete(u.cache, sessionID)
+	u.mu.Unlock()
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Msg("SessionUsecase.RevokeSession: session revoked successfully")
+
+	return nil
+}
+
+// pruneLocked drops expired cache entries and, when every entry is still
+// live, the one closest to expiring, so the cache stays within
+// maxCachedSessions. An evicted session is just validated again. u.mu must
+// be held.
+func (u *SessionUsecase) pruneLocked(now time.Time) {
+	var oldest string
+	for id, entry := range u.cache {
+		if now.After(entry.validUntil) {
+			delete(u.cache, id)
+		} else if oldest == "" || entry.validUntil.Before(u.cache[oldest].validUntil) {
+			oldest = id
+		}
+	}
+	if len(u.cache) >= maxCachedSessions {
+		delete(u.cache, oldest)
+	}
+}
@@ -2,9 +2,13 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/luthfiarsyad/mms/internal/domain/transaction"
 	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/metrics"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/rs/zerolog"
 )
 
 type TransactionUsecase struct {
@@ -16,8 +20,11 @@ func NewTransactionUsecase(txService *transaction.Service) *TransactionUsecase {
 	return &TransactionUsecase{txService: txService}
 }
 
-func (u *TransactionUsecase) CreateTransaction(ctx context.Context, userID int64, amount float64, description, txType string) (*transaction.Transaction, error) {
-	logger.L.Info().
+func (u *TransactionUsecase) CreateTransaction(ctx context.Context, userID int64, amount float64, description, txType string) (_ *transaction.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.CreateTransaction")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
 		Int64("user_id", userID).
 		Float64("amount", amount).
 		Str("type", txType).
@@ -30,9 +37,9 @@ func (u *TransactionUsecase) CreateTransaction(ctx context.Context, userID int64
 		Type:        txType,
 	}
 
-	err := u.txService.Create(ctx, t)
+	err = u.txService.Create(ctx, t)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Int64("user_id", userID).
 			Float64("amount", amount).
@@ -41,106 +48,236 @@ func (u *TransactionUsecase) CreateTransaction(ctx context.Context, userID int64
 		return nil, err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", t.ID).
 		Int64("user_id", userID).
 		Float64("amount", amount).
 		Str("type", txType).
 		Msg("TransactionUsecase.CreateTransaction: transaction created successfully")
+	metrics.ObserveTransactionCreated(t.Type)
 
 	return t, nil
 }
 
-func (u *TransactionUsecase) GetTransactionByID(ctx context.Context, id int64) (*transaction.Transaction, error) {
-	logger.L.Info().
+func (u *TransactionUsecase) GetTransactionByID(ctx context.Context, id int64) (_ *transaction.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.GetTransactionByID")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", id).
 		Msg("TransactionUsecase.GetTransactionByID: fetching transaction")
 
 	t, err := u.txService.GetByID(ctx, id)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Int64("transaction_id", id).
 			Msg("TransactionUsecase.GetTransactionByID: failed to fetch transaction")
 		return nil, err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", id).
 		Msg("TransactionUsecase.GetTransactionByID: transaction fetched successfully")
 
 	return t, nil
 }
 
-func (u *TransactionUsecase) GetUserTransactions(ctx context.Context, userID int64) ([]*transaction.Transaction, error) {
-	logger.L.Info().
+// GetUserTransactions returns one page of the user's transactions, newest
+// first, and how many the user has in total.
+func (u *TransactionUsecase) GetUserTransactions(ctx context.Context, userID int64, page transaction.Page) (_ []*transaction.Transaction, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.GetUserTransactions")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
 		Int64("user_id", userID).
 		Msg("TransactionUsecase.GetUserTransactions: fetching user transactions")
 
-	transactions, err := u.txService.GetByUserID(ctx, userID)
+	transactions, total, err := u.txService.GetByUserID(ctx, userID, page)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Int64("user_id", userID).
 			Msg("TransactionUsecase.GetUserTransactions: failed to fetch user transactions")
-		return nil, err
+		return nil, 0, err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Int64("user_id", userID).
 		Int("count", len(transactions)).
+		Int("total", total).
 		Msg("TransactionUsecase.GetUserTransactions: transactions fetched successfully")
 
-	return transactions, nil
+	return transactions, total, nil
 }
 
-func (u *TransactionUsecase) UpdateTransaction(ctx context.Context, id int64, amount float64, description, txType string) (*transaction.Transaction, error) {
-	logger.L.Info().
+// UpdateTransaction applies p to the transaction if it is still at version
+// and returns the updated transaction.
+func (u *TransactionUsecase) UpdateTransaction(ctx context.Context, id, version int64, p transaction.Patch) (_ *transaction.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.UpdateTransaction")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", id).
-		Float64("amount", amount).
-		Str("type", txType).
+		Int64("version", version).
 		Msg("TransactionUsecase.UpdateTransaction: updating transaction")
 
-	t := &transaction.Transaction{
-		ID:          id,
-		Amount:      amount,
-		Description: description,
-		Type:        txType,
-	}
-
-	err := u.txService.Update(ctx, t)
+	t, err := u.txService.Update(ctx, id, version, p)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Int64("transaction_id", id).
+			Int64("version", version).
 			Msg("TransactionUsecase.UpdateTransaction: failed to update transaction")
 		return nil, err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", id).
+		Int64("version", t.Version).
 		Msg("TransactionUsecase.UpdateTransaction: transaction updated successfully")
 
 	return t, nil
 }
 
-func (u *TransactionUsecase) DeleteTransaction(ctx context.Context, id int64) error {
-	logger.L.Info().
+func (u *TransactionUsecase) DeleteTransaction(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.DeleteTransaction")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", id).
-		Msg("TransactionUsecase.DeleteTransaction: deleting transaction")
+		Msg("TransactionUsecase.DeleteTransaction: moving transaction to trash")
 
-	err := u.txService.Delete(ctx, id)
+	err = u.txService.Delete(ctx, id)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Int64("transaction_id", id).
 			Msg("TransactionUsecase.DeleteTransaction: failed to delete transaction")
 		return err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Int64("transaction_id", id).
 		Msg("TransactionUsecase.DeleteTransaction: transaction deleted successfully")
 
 	return nil
 }
+
+// GetUserTrash returns one page of the user's deleted transactions, most
+// recently deleted first, and how many are in the trash.
+func (u *TransactionUsecase) GetUserTrash(ctx context.Context, userID int64, page transaction.Page) (_ []*transaction.Transaction, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.GetUserTrash")
+	defer tracing.End(span, &err)
+
+	transactions, total, err := u.txService.Trash(ctx, userID, page)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("TransactionUsecase.GetUserTrash: failed to fetch trash")
+		return nil, 0, err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Int("count", len(transactions)).
+		Int("total", total).
+		Msg("TransactionUsecase.GetUserTrash: trash fetched successfully")
+
+	return transactions, total, nil
+}
+
+// RestoreTransaction takes the user's transaction out of the trash and
+// returns it.
+func (u *TransactionUsecase) RestoreTransaction(ctx context.Context, id, userID int64) (_ *transaction.Transaction, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.RestoreTransaction")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("transaction_id", id).
+		Int64("user_id", userID).
+		Msg("TransactionUsecase.RestoreTransaction: restoring transaction")
+
+	if err = u.txService.Restore(ctx, id, userID); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("transaction_id", id).
+			Msg("TransactionUsecase.RestoreTransaction: failed to restore transaction")
+		return nil, err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int64("transaction_id", id).
+		Msg("TransactionUsecase.RestoreTransaction: transaction restored successfully")
+
+	return u.txService.GetByID(ctx, id)
+}
+
+// BatchTransactions runs ops for the user; see transaction.Service.Batch.
+func (u *TransactionUsecase) BatchTransactions(ctx context.Context, userID int64, ops []transaction.Operation, atomic bool) (_ []transaction.Result, err error) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.BatchTransactions")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Int("operations", len(ops)).
+		Bool("atomic", atomic).
+		Msg("TransactionUsecase.BatchTransactions: running batch")
+
+	results, err := u.txService.Batch(ctx, userID, ops, atomic)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("TransactionUsecase.BatchTransactions: batch failed")
+		return results, err
+	}
+
+	failed := 0
+	for i, r := range results {
+		if r.Err != nil {
+			failed++
+		} else if ops[i].Kind == transaction.OpCreate {
+			metrics.ObserveTransactionCreated(r.Transaction.Type)
+		}
+	}
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Int("succeeded", len(results)-failed).
+		Int("failed", failed).
+		Msg("TransactionUsecase.BatchTransactions: batch completed")
+
+	return results, nil
+}
+
+// RunTrashPurge permanently deletes transactions that have been in the
+// trash for longer than retention, now and then every interval, until ctx
+// is done.
+func (u *TransactionUsecase) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		u.purgeTrash(ctx, retention)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *TransactionUsecase) purgeTrash(ctx context.Context, retention time.Duration) {
+	ctx, span := tracing.Start(ctx, "TransactionUsecase.PurgeTrash")
+	var err error
+	defer tracing.End(span, &err)
+
+	purged, err := u.txService.Purge(ctx, retention)
+	if err != nil {
+		logger.L.Error().Err(err).Msg("TransactionUsecase.PurgeTrash: failed to purge trash")
+		return
+	}
+	if purged > 0 {
+		logger.L.Info().Int64("count", purged).Msg("TransactionUsecase.PurgeTrash: purged trashed transactions")
+	}
+}
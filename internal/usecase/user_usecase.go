@@ -3,90 +3,315 @@ package usecase
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"time"
 
+	"github.com/luthfiarsyad/mms/internal/domain/session"
 	"github.com/luthfiarsyad/mms/internal/domain/user"
 	"github.com/luthfiarsyad/mms/internal/infrastructure/logger"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/metrics"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/security"
+	"github.com/luthfiarsyad/mms/internal/infrastructure/tracing"
+	"github.com/rs/zerolog"
 )
 
+// DefaultTokenTTL is the lifetime of access tokens and their login sessions
+// unless SetTokenTTL changes it.
+const DefaultTokenTTL = 24 * time.Hour
+
 type AuthUsecase struct {
-	userService *user.Service
-	paseto      PasetoService
+	userService    *user.Service
+	sessionService *session.Service
+	paseto         PasetoService
+	hasher         security.PasswordHasher
+	policy         *security.PasswordPolicy
+	tokenTTL       atomic.Int64 // time.Duration
 }
 
 // PasetoService minimal interface for token creation/validation
 type PasetoService interface {
-	CreateToken(userID int64, exp time.Duration) (string, error)
-	VerifyToken(token string) (int64, error)
+	CreateToken(userID int64, sessionID string, exp time.Duration) (string, error)
+	VerifyToken(token string) (*security.TokenClaims, error)
 }
 
-func NewAuthUsecase(us *user.Service, p PasetoService) *AuthUsecase {
+func NewAuthUsecase(us *user.Service, ss *session.Service, p PasetoService, h security.PasswordHasher, policy *security.PasswordPolicy) *AuthUsecase {
 	logger.L.Debug().Msg("AuthUsecase: initialized")
-	return &AuthUsecase{userService: us, paseto: p}
+	a := &AuthUsecase{userService: us, sessionService: ss, paseto: p, hasher: h, policy: policy}
+	a.tokenTTL.Store(int64(DefaultTokenTTL))
+	return a
+}
+
+// SetTokenTTL changes the lifetime of tokens issued from now on; d <= 0
+// restores DefaultTokenTTL. It is safe to call while logins are running.
+func (a *AuthUsecase) SetTokenTTL(d time.Duration) {
+	if d <= 0 {
+		d = DefaultTokenTTL
+	}
+	a.tokenTTL.Store(int64(d))
 }
 
-func (a *AuthUsecase) Register(ctx context.Context, u *user.User, hashedPassword string) error {
-	logger.L.Info().
+func (a *AuthUsecase) Register(ctx context.Context, u *user.User, password string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.Register")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
 		Str("email", u.Email).
 		Msg("AuthUsecase.Register: start user registration")
 
-	u.Password = hashedPassword
+	if err := a.policy.Validate(password, u.Email, u.Name); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Str("email", u.Email).
+			Msg("AuthUsecase.Register: password rejected by policy")
+		return err
+	}
+
+	hashed, err := a.hasher.Hash(password)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Str("email", u.Email).
+			Msg("AuthUsecase.Register: failed to hash password")
+		return err
+	}
+
+	u.Password = hashed
 	u.CreatedAt = time.Now()
 
-	err := a.userService.Register(ctx, u)
+	err = a.userService.Register(ctx, u)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Str("email", u.Email).
 			Msg("AuthUsecase.Register: failed to register user")
 		return err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Str("email", u.Email).
 		Int64("user_id", u.ID).
 		Msg("AuthUsecase.Register: registration successful")
+	metrics.ObserveRegistration()
 
 	return nil
 }
 
-func (a *AuthUsecase) Login(ctx context.Context, email, password string,
-	passwordCheck func(hashed, plain string) error) (string, error) {
+// Login verifies the credentials, records a session for the client and
+// returns an access token referencing that session, valid for ttl.
+func (a *AuthUsecase) Login(ctx context.Context, email, password, clientIP, userAgent string) (token string, ttl time.Duration, err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.Login")
+	defer tracing.End(span, &err)
+	defer func() { metrics.ObserveLogin(err == nil) }()
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Str("email", email).
 		Msg("AuthUsecase.Login: login attempt")
 
 	u, err := a.userService.Authenticate(ctx, email, password)
 	if err != nil {
-		logger.L.Warn().
+		zerolog.Ctx(ctx).Warn().
 			Err(err).
 			Str("email", email).
 			Msg("AuthUsecase.Login: authentication failed")
-		return "", err
+		return "", 0, err
 	}
 
-	if err := passwordCheck(u.Password, password); err != nil {
-		logger.L.Warn().
+	if err := a.hasher.Verify(u.Password, password); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
 			Str("email", email).
 			Msg("AuthUsecase.Login: invalid password")
-		return "", errors.New("invalid credentials")
+		return "", 0, user.ErrInvalidCreds
+	}
+
+	a.rehashIfNeeded(ctx, u, password)
+
+	ttl = time.Duration(a.tokenTTL.Load())
+	sess, err := a.sessionService.Start(ctx, u.ID, clientIP, userAgent, ttl)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", u.ID).
+			Str("email", u.Email).
+			Msg("AuthUsecase.Login: failed to start session")
+		return "", 0, err
 	}
 
-	token, err := a.paseto.CreateToken(u.ID, 24*time.Hour)
+	token, err = a.paseto.CreateToken(u.ID, sess.ID, ttl)
 	if err != nil {
-		logger.L.Error().
+		zerolog.Ctx(ctx).Error().
 			Err(err).
 			Int64("user_id", u.ID).
 			Str("email", u.Email).
 			Msg("AuthUsecase.Login: failed to create token")
-		return "", err
+		return "", 0, err
 	}
 
-	logger.L.Info().
+	zerolog.Ctx(ctx).Info().
 		Int64("user_id", u.ID).
 		Str("email", u.Email).
 		Msg("AuthUsecase.Login: login success")
 
-	return token, nil
+	return token, ttl, nil
+}
+
+// ChangePassword replaces the password of userID after verifying the current
+// one. The new password must satisfy the password policy.
+func (a *AuthUsecase) ChangePassword(ctx context.Context, userID int64, currentPassword, newPassword string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.ChangePassword")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Msg("AuthUsecase.ChangePassword: start password change")
+
+	u, err := a.userService.GetByID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ChangePassword: failed to load user")
+		return err
+	}
+
+	if err := a.hasher.Verify(u.Password, currentPassword); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ChangePassword: invalid current password")
+		return user.ErrInvalidCreds
+	}
+
+	if err := a.policy.Validate(newPassword, u.Email, u.Name); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ChangePassword: password rejected by policy")
+		return err
+	}
+
+	hashed, err := a.hasher.Hash(newPassword)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ChangePassword: failed to hash password")
+		return err
+	}
+
+	if err := a.userService.ChangePassword(ctx, userID, hashed); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ChangePassword: failed to store password")
+		return err
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Msg("AuthUsecase.ChangePassword: password changed")
+
+	return nil
+}
+
+// ResetPassword sets a new password for userID without the current one, as
+// an operator does for a user who lost theirs. The password must satisfy the
+// password policy, and every session of the user is revoked.
+func (a *AuthUsecase) ResetPassword(ctx context.Context, userID int64, newPassword string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthUsecase.ResetPassword")
+	defer tracing.End(span, &err)
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Msg("AuthUsecase.ResetPassword: start password reset")
+
+	u, err := a.userService.GetByID(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ResetPassword: failed to load user")
+		return err
+	}
+
+	if err := a.policy.Validate(newPassword, u.Email, u.Name); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ResetPassword: password rejected by policy")
+		return err
+	}
+
+	hashed, err := a.hasher.Hash(newPassword)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ResetPassword: failed to hash password")
+		return err
+	}
+
+	if err := a.userService.ChangePassword(ctx, userID, hashed); err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ResetPassword: failed to store password")
+		return err
+	}
+
+	sessions, err := a.sessionService.ListActive(ctx, userID)
+	if err != nil {
+		zerolog.Ctx(ctx).Error().
+			Err(err).
+			Int64("user_id", userID).
+			Msg("AuthUsecase.ResetPassword: failed to list sessions")
+		return err
+	}
+	for _, sess := range sessions {
+		if err := a.sessionService.Revoke(ctx, sess.ID, userID); err != nil && !errors.Is(err, session.ErrSessionNotFound) {
+			zerolog.Ctx(ctx).Error().
+				Err(err).
+				Int64("user_id", userID).
+				Msg("AuthUsecase.ResetPassword: failed to revoke session")
+			return err
+		}
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", userID).
+		Int("revoked_sessions", len(sessions)).
+		Msg("AuthUsecase.ResetPassword: password reset")
+
+	return nil
+}
+
+// rehashIfNeeded upgrades a stored hash that was produced with an outdated
+// algorithm or parameters. It only runs after a successful Verify, since that
+// is the only time the plain password is available. Failures are logged and
+// never fail the login.
+func (a *AuthUsecase) rehashIfNeeded(ctx context.Context, u *user.User, password string) {
+	if !a.hasher.NeedsRehash(u.Password) {
+		return
+	}
+
+	hashed, err := a.hasher.Hash(password)
+	if err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Int64("user_id", u.ID).
+			Msg("AuthUsecase.Login: failed to rehash password")
+		return
+	}
+	if err := a.userService.UpdatePassword(ctx, u.ID, hashed); err != nil {
+		zerolog.Ctx(ctx).Warn().
+			Err(err).
+			Int64("user_id", u.ID).
+			Msg("AuthUsecase.Login: failed to store rehashed password")
+		return
+	}
+	u.Password = hashed
+
+	zerolog.Ctx(ctx).Info().
+		Int64("user_id", u.ID).
+		Msg("AuthUsecase.Login: password hash upgraded")
 }
@@ -0,0 +1,19 @@
+// Package actor carries the authenticated user of the current request
+// through context.Context, so lower layers can attribute their work without
+// taking the user ID as a parameter.
+package actor
+
+import "context"
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, ctxKey{}, userID)
+}
+
+// UserID returns the user of ctx; ok is false outside an authenticated
+// request.
+func UserID(ctx context.Context) (id int64, ok bool) {
+	id, ok = ctx.Value(ctxKey{}).(int64)
+	return id, ok
+}
@@ -0,0 +1,18 @@
+// Package clientip carries the address of the client of the current request
+// through context.Context, so lower layers can record where a change came
+// from.
+package clientip
+
+import "context"
+
+type ctxKey struct{}
+
+func NewContext(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, ip)
+}
+
+// FromContext returns the client address of ctx, or "" outside a request.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(ctxKey{}).(string)
+	return ip
+}
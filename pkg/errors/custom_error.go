@@ -1 +1,142 @@
-package errors
\ No newline at end of file
+// Package errors defines AppError, the single error type rendered to API
+// clients. It carries a stable machine-readable code, the HTTP status, a
+// message that is safe to show, optional details and the wrapped cause,
+// which is logged but never rendered.
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Generic error codes. Domain specific codes live next to their mapping.
+const (
+	CodeBadRequest      = "BAD_REQUEST"
+	CodeValidation      = "VALIDATION_FAILED"
+	CodeUnauthorized    = "UNAUTHORIZED"
+	CodeForbidden       = "FORBIDDEN"
+	CodeNotFound        = "NOT_FOUND"
+	CodeConflict        = "CONFLICT"
+	CodeTooManyRequests = "TOO_MANY_REQUESTS"
+	CodeNotImplemented  = "NOT_IMPLEMENTED"
+	CodeInternal        = "INTERNAL_ERROR"
+)
+
+type AppError struct {
+	Code    string
+	Status  int
+	Message string
+	Details any
+	Err     error
+}
+
+// New returns an AppError without cause or details.
+func New(code string, status int, message string) *AppError {
+	return &AppError{Code: code, Status: status, Message: message}
+}
+
+func BadRequest(code, message string) *AppError {
+	return New(code, http.StatusBadRequest, message)
+}
+
+func Unauthorized(code, message string) *AppError {
+	return New(code, http.StatusUnauthorized, message)
+}
+
+func Forbidden(code, message string) *AppError {
+	return New(code, http.StatusForbidden, message)
+}
+
+func NotFound(code, message string) *AppError {
+	return New(code, http.StatusNotFound, message)
+}
+
+func Conflict(code, message string) *AppError {
+	return New(code, http.StatusConflict, message)
+}
+
+// Internal hides cause behind a generic message.
+func Internal(cause error) *AppError {
+	return New(CodeInternal, http.StatusInternalServerError, "internal server error").Wrap(cause)
+}
+
+func (e *AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error { return e.Err }
+
+// Is matches any AppError with the same code, so a sentinel still matches
+// after Wrap or WithDetails returned a copy of it.
+func (e *AppError) Is(target error) bool {
+	var t *AppError
+	if !errors.As(target, &t) {
+		return false
+	}
+	return t.Code == e.Code
+}
+
+// Wrap returns a copy of e with cause attached.
+func (e *AppError) Wrap(cause error) *AppError {
+	c := *e
+	c.Err = cause
+	return &c
+}
+
+// WithDetails returns a copy of e carrying details for the client.
+func (e *AppError) WithDetails(details any) *AppError {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithMessage returns a copy of e with a different client-facing message.
+func (e *AppError) WithMessage(message string) *AppError {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+type mapping struct {
+	target error
+	app    *AppError
+}
+
+var (
+	mu       sync.RWMutex
+	mappings []mapping
+)
+
+// Register maps a sentinel error (matched with errors.Is) onto an AppError,
+// so lower layers can keep returning their own errors.
+func Register(target error, app *AppError) {
+	mu.Lock()
+	defer mu.Unlock()
+	mappings = append(mappings, mapping{target: target, app: app})
+}
+
+// From converts any error into an AppError. AppErrors in the chain are
+// returned as is, registered sentinels are translated, and everything else
+// becomes an internal error.
+func From(err error) *AppError {
+	if err == nil {
+		return nil
+	}
+	var app *AppError
+	if errors.As(err, &app) {
+		return app
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, m := range mappings {
+		if errors.Is(err, m.target) {
+			return m.app.Wrap(err)
+		}
+	}
+	return Internal(err)
+}